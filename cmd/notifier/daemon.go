@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/github-insight-analyze/trending-notifier/internal/config"
+	"github.com/github-insight-analyze/trending-notifier/pkg/schedule"
+	"github.com/github-insight-analyze/trending-notifier/utils"
+)
+
+// 等待下一次运行时最长的休眠时间，系统休眠或调整时钟后能及时发现到期的报告
+const maxDaemonSleep = time.Minute
+
+// job 守护进程中定时运行的一个报告
+type job struct {
+	profile  config.ReportConfig
+	schedule *schedule.Schedule
+	next     time.Time // 下一次计划运行的时间，补发时为错过的时间
+}
+
+// scheduleState 持久化的运行记录，重启后据此补发停机期间错过的运行
+type scheduleState struct {
+	Reports map[string]jobState `json:"reports"`
+}
+
+// jobState 单个报告的运行记录
+type jobState struct {
+	LastScheduled time.Time `json:"last_scheduled"`       // 最近一次计划运行的时间
+	LastRun       time.Time `json:"last_run"`             // 最近一次实际运行的时间
+	LastError     string    `json:"last_error,omitempty"` // 最近一次运行的错误
+}
+
+// runDaemon 按每个报告的 cron 表达式定时运行，直到收到 SIGINT 或 SIGTERM
+// 收到信号时等待正在进行的运行结束后退出，再次收到信号时立即取消
+func runDaemon(cfg *config.Config) error {
+	// 同名的守护进程只允许运行一个，避免重复发送
+	mutex, err := utils.CreateNamedMutex(cfg.Schedule.LockName)
+	if err != nil {
+		return fmt.Errorf("another daemon is already running: %w", err)
+	}
+	defer mutex.Release()
+
+	jobs, err := newJobs(cfg.ReportProfiles())
+	if err != nil {
+		return err
+	}
+
+	state, err := loadScheduleState(cfg.Schedule.StateFile)
+	if err != nil {
+		return err
+	}
+
+	window := time.Duration(cfg.Schedule.CatchUpWindow) * time.Hour
+	catchUp := strings.EqualFold(cfg.Schedule.CatchUp, "once")
+	if planJobs(jobs, state, time.Now(), window, catchUp) {
+		if err := saveScheduleState(cfg.Schedule.StateFile, state); err != nil {
+			log.Printf("Warning: failed to save schedule state: %v", err)
+		}
+	}
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	log.Printf("Daemon started (pid %d), state file: %s", os.Getpid(), cfg.Schedule.StateFile)
+	for _, j := range jobs {
+		log.Printf("- Report %s: schedule=%q, timezone=%s, next run at %s",
+			j.profile.Name, j.schedule.String(), j.schedule.Location(), formatScheduleTime(j.next))
+	}
+
+	for {
+		now := time.Now()
+		var due []*job
+		next := now.Add(maxDaemonSleep)
+		for _, j := range jobs {
+			if !j.next.After(now) {
+				due = append(due, j)
+			} else if j.next.Before(next) {
+				next = j.next
+			}
+		}
+
+		if len(due) == 0 {
+			timer := time.NewTimer(next.Sub(now))
+			select {
+			case <-timer.C:
+				continue
+			case sig := <-signals:
+				timer.Stop()
+				log.Printf("Received %s, daemon stopped", sig)
+				return nil
+			}
+		}
+
+		outcome := runJobs(cfg, due, signals)
+		for _, j := range due {
+			state.Reports[j.profile.Name] = jobState{
+				LastScheduled: j.next,
+				LastRun:       now,
+				LastError:     errorString(outcome.err),
+			}
+			j.next = j.schedule.Next(time.Now())
+			if !outcome.stopped {
+				log.Printf("Next run of report %s at %s", j.profile.Name, formatScheduleTime(j.next))
+			}
+		}
+		if err := saveScheduleState(cfg.Schedule.StateFile, state); err != nil {
+			log.Printf("Warning: failed to save schedule state: %v", err)
+		}
+
+		if outcome.stopped {
+			log.Printf("Received %s, daemon stopped", outcome.signal)
+			return nil
+		}
+	}
+}
+
+// planJobs 计算每个报告下一次运行的时间，catchUp 为 true 时立即补发错过的运行
+// 不补发时把错过的运行记为最近一次计划运行的时间，下次启动不会再次发现同一次错过的运行
+// 返回 state 是否被修改
+func planJobs(jobs []*job, state *scheduleState, now time.Time, window time.Duration, catchUp bool) bool {
+	changed := false
+	for _, j := range jobs {
+		j.next = j.schedule.Next(now)
+		last, ok := state.Reports[j.profile.Name]
+		if !ok || last.LastScheduled.IsZero() {
+			continue
+		}
+		missed := lastMissed(j.schedule, last.LastScheduled, now, window)
+		if missed.IsZero() {
+			continue
+		}
+		if catchUp {
+			log.Printf("Report %s missed its run at %s, catching up now", j.profile.Name, formatScheduleTime(missed))
+			j.next = missed
+		} else {
+			log.Printf("Report %s missed its run at %s, skipped (catch_up: skip)", j.profile.Name, formatScheduleTime(missed))
+			last.LastScheduled = missed
+			state.Reports[j.profile.Name] = last
+			changed = true
+		}
+	}
+	return changed
+}
+
+// runOutcome 一次定时运行的结果
+type runOutcome struct {
+	err     error
+	stopped bool      // 运行期间收到了退出信号
+	signal  os.Signal // 收到的退出信号
+}
+
+// runJobs 运行到期的报告，同时运行的报告共享抓取结果
+// 运行期间收到第一个信号时等待运行结束，收到第二个信号时取消运行
+func runJobs(cfg *config.Config, due []*job, signals <-chan os.Signal) runOutcome {
+	profiles := make([]config.ReportConfig, 0, len(due))
+	names := make([]string, 0, len(due))
+	for _, j := range due {
+		profiles = append(profiles, j.profile)
+		names = append(names, j.profile.Name)
+	}
+	log.Printf("========== Scheduled run: %s ==========", strings.Join(names, ", "))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- run(ctx, cfg, profiles)
+	}()
+
+	var outcome runOutcome
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				log.Printf("Scheduled run failed: %v", err)
+			}
+			outcome.err = err
+			return outcome
+		case sig := <-signals:
+			if outcome.stopped {
+				log.Printf("Received %s again, cancelling the current run", sig)
+				cancel()
+				continue
+			}
+			outcome.stopped = true
+			outcome.signal = sig
+			log.Printf("Received %s, waiting for the current run to finish (send again to cancel)", sig)
+		}
+	}
+}
+
+// newJobs 解析每个报告的 cron 表达式，守护进程模式下所有报告都必须配置 schedule
+func newJobs(profiles []config.ReportConfig) ([]*job, error) {
+	jobs := make([]*job, 0, len(profiles))
+	for _, profile := range profiles {
+		s, err := profile.ParseSchedule()
+		if err != nil {
+			return nil, fmt.Errorf("report %s: %w", profile.Name, err)
+		}
+		if s == nil {
+			return nil, fmt.Errorf("report %s has no schedule, set schedule.cron or the report's schedule to use -daemon", profile.Name)
+		}
+		jobs = append(jobs, &job{profile: profile, schedule: s})
+	}
+	return jobs, nil
+}
+
+// lastMissed 返回 (last, now] 之间最后一次应当运行的时间，window 大于 0 时只查找 now 之前 window 内的运行
+// 没有错过的运行时返回零值
+func lastMissed(s *schedule.Schedule, last, now time.Time, window time.Duration) time.Time {
+	from := last
+	if window > 0 && from.Before(now.Add(-window)) {
+		from = now.Add(-window)
+	}
+	var missed time.Time
+	for t := s.Next(from); !t.IsZero() && !t.After(now); t = s.Next(t) {
+		missed = t
+	}
+	return missed
+}
+
+// loadScheduleState 读取运行记录，文件不存在时返回空记录
+func loadScheduleState(path string) (*scheduleState, error) {
+	state := &scheduleState{Reports: make(map[string]jobState)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schedule state: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse schedule state %s: %w", path, err)
+	}
+	if state.Reports == nil {
+		state.Reports = make(map[string]jobState)
+	}
+	return state, nil
+}
+
+// saveScheduleState 先写入临时文件再重命名，避免中途退出留下损坏的文件
+func saveScheduleState(path string, state *scheduleState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode schedule state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write schedule state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write schedule state: %w", err)
+	}
+	return nil
+}
+
+// formatScheduleTime 带时区的运行时间，如 "2024-01-02 07:30 CST"
+func formatScheduleTime(t time.Time) string {
+	return t.Format("2006-01-02 15:04 MST")
+}
+
+// errorString 错误信息，err 为 nil 时返回空字符串
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/github-insight-analyze/trending-notifier/internal/config"
+	"github.com/github-insight-analyze/trending-notifier/pkg/schedule"
+)
+
+func TestLastMissed(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(location *time.Location, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, location)
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		location *time.Location
+		last     time.Time
+		now      time.Time
+		window   time.Duration
+		want     time.Time // 零值表示没有错过的运行
+	}{
+		{"nothing missed", "30 7 * * *", shanghai,
+			at(shanghai, 5, 4, 7, 30), at(shanghai, 5, 5, 7, 0), 0, time.Time{}},
+		{"one missed", "30 7 * * *", shanghai,
+			at(shanghai, 5, 4, 7, 30), at(shanghai, 5, 5, 9, 0), 0, at(shanghai, 5, 5, 7, 30)},
+		{"latest of several", "30 7 * * *", shanghai,
+			at(shanghai, 5, 1, 7, 30), at(shanghai, 5, 5, 9, 0), 0, at(shanghai, 5, 5, 7, 30)},
+		{"due exactly now", "30 7 * * *", shanghai,
+			at(shanghai, 5, 4, 7, 30), at(shanghai, 5, 5, 7, 30), 0, at(shanghai, 5, 5, 7, 30)},
+		{"across month boundary", "0 8 1 * *", shanghai,
+			at(shanghai, 4, 1, 8, 0), at(shanghai, 5, 3, 0, 0), 0, at(shanghai, 5, 1, 8, 0)},
+		{"inside window", "30 7 * * *", shanghai,
+			at(shanghai, 5, 1, 7, 30), at(shanghai, 5, 5, 6, 0), 24 * time.Hour, at(shanghai, 5, 4, 7, 30)},
+		{"outside window", "30 7 * * *", shanghai,
+			at(shanghai, 5, 1, 7, 30), at(shanghai, 5, 5, 6, 0), 12 * time.Hour, time.Time{}},
+		{"weekly outside window", "0 9 * * MON", shanghai,
+			at(shanghai, 4, 27, 9, 0), at(shanghai, 5, 6, 12, 0), 48 * time.Hour, time.Time{}},
+		// 2026-11-01 01:00-01:59 出现两次，01:30 只运行一次
+		{"fall back", "30 1 * * *", newYork,
+			at(newYork, 10, 31, 1, 30), at(newYork, 11, 1, 0, 45).Add(2 * time.Hour), 0, at(newYork, 11, 1, 0, 30).Add(time.Hour)},
+		// 2026-03-08 02:30 不存在，当天没有错过的运行
+		{"spring forward", "30 2 * * *", newYork,
+			at(newYork, 3, 7, 2, 30), at(newYork, 3, 8, 12, 0), 0, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		s, err := schedule.Parse(tt.expr, tt.location)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := lastMissed(s, tt.last, tt.now, tt.window)
+		if !got.Equal(tt.want) {
+			t.Errorf("%s: lastMissed = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPlanJobs(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := schedule.Parse("30 7 * * *", shanghai)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lastRun := time.Date(2026, 5, 4, 7, 30, 5, 0, shanghai)
+	missed := time.Date(2026, 5, 5, 7, 30, 0, 0, shanghai)
+	now := time.Date(2026, 5, 5, 9, 0, 0, 0, shanghai)
+	tomorrow := time.Date(2026, 5, 6, 7, 30, 0, 0, shanghai)
+
+	for _, catchUp := range []bool{true, false} {
+		state := &scheduleState{Reports: map[string]jobState{
+			"daily": {LastScheduled: lastRun.Truncate(time.Minute), LastRun: lastRun},
+		}}
+		jobs := []*job{
+			{profile: config.ReportConfig{Name: "daily"}, schedule: s},
+			{profile: config.ReportConfig{Name: "new"}, schedule: s},
+		}
+
+		changed := planJobs(jobs, state, now, 0, catchUp)
+
+		if !jobs[1].next.Equal(tomorrow) {
+			t.Errorf("catchUp=%v: report without state runs at %v, want %v", catchUp, jobs[1].next, tomorrow)
+		}
+		if catchUp {
+			if !jobs[0].next.Equal(missed) || changed {
+				t.Errorf("catch up: next = %v, changed = %v, want %v and state unchanged", jobs[0].next, changed, missed)
+			}
+			continue
+		}
+
+		// 跳过时记录错过的运行，下次启动不再发现它
+		got := state.Reports["daily"]
+		if !jobs[0].next.Equal(tomorrow) || !changed || !got.LastScheduled.Equal(missed) || !got.LastRun.Equal(lastRun) {
+			t.Errorf("skip: next = %v, changed = %v, state = %+v, want next %v and last_scheduled %v", jobs[0].next, changed, got, tomorrow, missed)
+		}
+		if planJobs(jobs, state, now.Add(time.Hour), 0, catchUp) {
+			t.Errorf("skip: the same missed run was found again after restarting")
+		}
+	}
+}
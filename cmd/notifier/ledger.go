@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/github-insight-analyze/trending-notifier/internal/config"
+	"github.com/github-insight-analyze/trending-notifier/pkg/ledger"
+	"github.com/github-insight-analyze/trending-notifier/utils"
+)
+
+// runLedger ledger 子命令：按条件列出发送账本中的记录，从新到旧
+// 只读取账本，不验证邮件等发送配置；读取期间持有发送锁，不会读到正在写入的记录
+func runLedger(args []string) error {
+	fs := flag.NewFlagSet("ledger", flag.ExitOnError)
+	cfgPath := fs.String("config", *configPath, "Path to configuration file")
+	path := fs.String("ledger", "", "Path to the ledger file, overrides ledger.path in the configuration")
+	report := fs.String("report", "", "Only show this report")
+	window := fs.String("window", "", "Only show this period window, e.g. 2024-01-02, 2024-W01 or 2024-01")
+	recipient := fs.String("recipient", "", "Only show this recipient, an email address or channel:<name>")
+	days := fs.Int("days", 0, "Only show deliveries from the last N days, 0 means all")
+	limit := fs.Int("limit", 50, "Show at most N entries, 0 means all")
+	asJSON := fs.Bool("json", false, "Print entries as JSON")
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	cfg, err := config.LoadUnvalidated(*cfgPath)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if *path != "" {
+		cfg.Ledger.Path = *path
+	}
+
+	// 与发送报告的运行使用同一把锁，避免读到写了一半的记录
+	mutex, err := utils.CreateNamedMutex(cfg.Ledger.LockName)
+	if err != nil {
+		return fmt.Errorf("a run is currently sending reports, try again later: %w", err)
+	}
+	l, err := ledger.Open(cfg.Ledger.Path)
+	mutex.Release()
+	if err != nil {
+		return err
+	}
+
+	filter := ledger.Filter{Report: *report, Window: *window, Recipient: *recipient}
+	if *days > 0 {
+		filter.Since = time.Now().AddDate(0, 0, -*days)
+	}
+	entries := l.List(filter)
+	total := len(entries)
+	if *limit > 0 && len(entries) > *limit {
+		entries = entries[:*limit]
+	}
+
+	if *asJSON {
+		if entries == nil {
+			entries = []ledger.Entry{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	}
+
+	if total == 0 {
+		fmt.Printf("No matching deliveries in %s\n", l.Path())
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SENT AT\tREPORT\tWINDOW\tRECIPIENT\tSUBJECT")
+	for _, entry := range entries {
+		subject := entry.Subject
+		if entry.Forced {
+			subject = strings.TrimSpace("(forced) " + subject)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", entry.SentAt.Local().Format("2006-01-02 15:04:05"),
+			entry.Report, entry.Window, entry.Recipient, subject)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(entries) < total {
+		fmt.Printf("... %d of %d entries shown, use -limit 0 to show all\n", len(entries), total)
+	}
+	return nil
+}
@@ -2,26 +2,52 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"net"
 	"os"
+	"strings"
 	"time"
+	// 内嵌时区数据库，在没有 zoneinfo 的系统（如 Windows、精简容器）上也能使用 schedule.timezone
+	_ "time/tzdata"
 
 	"github.com/github-insight-analyze/trending-notifier/internal/config"
 	"github.com/github-insight-analyze/trending-notifier/pkg/api"
 	"github.com/github-insight-analyze/trending-notifier/pkg/email"
-	"github.com/github-insight-analyze/trending-notifier/pkg/formatter"
+	"github.com/github-insight-analyze/trending-notifier/pkg/ledger"
+	"github.com/github-insight-analyze/trending-notifier/pkg/notify"
+	"github.com/github-insight-analyze/trending-notifier/pkg/store"
+	"github.com/github-insight-analyze/trending-notifier/utils"
 )
 
 var (
-	configPath = flag.String("config", "", "Path to configuration file")
-	version    = flag.Bool("version", false, "Show version information")
+	configPath   = flag.String("config", "", "Path to configuration file")
+	version      = flag.Bool("version", false, "Show version information")
+	filterDryRun = flag.Bool("filter-dry-run", false, "Fetch and filter repositories, list what was filtered and why, without sending emails")
+	output       = flag.String("output", "", "Write each report to a file, or \"-\" for stdout; use {name} in the path when several reports are configured")
+	noEmail      = flag.Bool("no-email", false, "Do not send emails or other notifications, only write reports to -output")
+	force        = flag.Bool("force", false, "Send reports again even if the send ledger shows they were already delivered for the current period")
+	daemon       = flag.Bool("daemon", false, "Keep running and send each report on its cron schedule (see schedule in the config)")
 )
 
 const appVersion = "1.0.0"
 
+// 退出码，便于 cron 和 CI 区分失败原因
+// 多个报告因不同原因失败时，按下面的顺序取第一个匹配的退出码
+const (
+	exitError       = 1 // 其他错误
+	exitUsage       = 2 // 命令行参数或配置错误
+	exitRateLimited = 3 // 数据源限流
+	exitBadRequest  = 4 // 数据源拒绝了请求，如不支持的语言（404）或参数错误（其他 4xx）
+	exitUnavailable = 5 // 数据源服务端错误、网络错误或已熔断
+	exitDecode      = 6 // 数据源响应无法解析
+	exitNotify      = 7 // 报告已生成但通知发送失败
+)
+
 func main() {
+	flag.Usage = usage
 	flag.Parse()
 
 	// 显示版本信息
@@ -30,101 +56,405 @@ func main() {
 		os.Exit(0)
 	}
 
+	// 子命令
+	switch flag.Arg(0) {
+	case "":
+	case "ledger":
+		if err := runLedger(flag.Args()[1:]); err != nil {
+			log.Printf("Ledger error: %v", err)
+			os.Exit(exitError)
+		}
+		return
+	default:
+		log.Printf("Unknown command %q, run with -help for usage", flag.Arg(0))
+		os.Exit(exitUsage)
+	}
+
 	// 加载配置
 	log.Println("Loading configuration...")
 	cfg, err := config.Load(*configPath)
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		log.Printf("Failed to load configuration: %v", err)
+		os.Exit(exitUsage)
 	}
 
 	log.Printf("Configuration loaded successfully")
-	log.Printf("- Language: %s", cfg.Query.Language)
-	log.Printf("- Period: %s", cfg.Query.Period)
-	log.Printf("- Limit: %d", cfg.Query.Limit)
-	log.Printf("- Recipients: %v", cfg.Email.To)
+	for _, profile := range cfg.ReportProfiles() {
+		log.Printf("- Report %s: language=%s, period=%s, limit=%d, ranking=%s, format=%s, recipients=%v",
+			profile.Name, profile.Query.Language, profile.Query.Period, profile.Query.Limit,
+			profile.Ranking.Strategy, profile.Format, profile.To)
+	}
+
+	if err := checkFlags(cfg.ReportProfiles()); err != nil {
+		log.Printf("Invalid arguments: %v", err)
+		os.Exit(exitUsage)
+	}
+
+	// 守护进程模式，按 cron 表达式定时运行，直到收到退出信号
+	if *daemon {
+		if err := runDaemon(cfg); err != nil {
+			log.Printf("Daemon error: %v", err)
+			os.Exit(exitError)
+		}
+		return
+	}
 
 	// 运行主逻辑
-	if err := run(cfg); err != nil {
-		log.Fatalf("Application error: %v", err)
+	if err := run(context.Background(), cfg, cfg.ReportProfiles()); err != nil {
+		log.Printf("Application error: %v", err)
+		os.Exit(exitCode(err))
 	}
 
-	log.Println("Email sent successfully!")
+	if *filterDryRun {
+		log.Println("Filter dry run finished, no emails sent")
+		return
+	}
+	if *noEmail {
+		log.Println("All reports written successfully!")
+		return
+	}
+	log.Println("All reports sent successfully!")
+}
+
+// usage 命令行帮助，包括子命令
+func usage() {
+	w := flag.CommandLine.Output()
+	fmt.Fprintf(w, "Usage:\n  %s [flags]\n  %s [flags] ledger [ledger flags]\n\nFlags:\n", os.Args[0], os.Args[0])
+	flag.PrintDefaults()
+	fmt.Fprintf(w, "\nCommands:\n  ledger\tList deliveries recorded in the send ledger, run \"ledger -help\" for its flags\n")
 }
 
-func run(cfg *config.Config) error {
-	ctx := context.Background()
+// checkFlags 验证命令行参数的组合
+func checkFlags(profiles []config.ReportConfig) error {
+	if *noEmail && *output == "" {
+		return fmt.Errorf("-no-email requires -output")
+	}
+	if *output != "" && *output != "-" && len(profiles) > 1 && !strings.Contains(*output, "{name}") {
+		return fmt.Errorf("-output must contain {name} when %d reports are configured", len(profiles))
+	}
+	if *daemon && *filterDryRun {
+		return fmt.Errorf("-daemon cannot be used with -filter-dry-run")
+	}
+	return nil
+}
+
+// run 生成并发送 profiles 中的报告
+func run(ctx context.Context, cfg *config.Config, profiles []config.ReportConfig) error {
+	sending := !*filterDryRun && !*noEmail
+
+	// 发送期间持有进程互斥锁，重叠的运行（如重试的 GitHub Actions 任务和 cron）不会同时发送
+	if sending {
+		mutex, err := utils.CreateNamedMutex(cfg.Ledger.LockName)
+		if err != nil {
+			return fmt.Errorf("another run is already sending reports: %w", err)
+		}
+		defer mutex.Release()
+	}
+
+	// 打开发送账本，跳过当前时间窗口内已经发送过的报告
+	var sendLedger *ledger.Ledger
+	if sending && cfg.Ledger.Enabled {
+		var err error
+		sendLedger, err = ledger.Open(cfg.Ledger.Path)
+		if err != nil {
+			return fmt.Errorf("failed to open send ledger: %w", err)
+		}
+		if *force {
+			log.Println("Force mode: reports already sent for the current period will be sent again")
+		}
+	}
 
 	// 创建API客户端
-	log.Println("Creating API client...")
-	apiClient := api.NewClient(
-		cfg.API.BaseURL,
-		time.Duration(cfg.API.Timeout)*time.Second,
-	)
-
-	// 获取trending repositories
-	log.Printf("Fetching trending repositories (language: %s, period: %s)...",
-		cfg.Query.Language, cfg.Query.Period)
-
-	repos, err := apiClient.GetTrendingRepos(
-		ctx,
-		cfg.Query.Language,
-		cfg.Query.Period,
-		cfg.Query.Limit,
-	)
+	timeout := time.Duration(cfg.API.Timeout) * time.Second
+	source, err := buildSource(cfg, timeout)
 	if err != nil {
-		return fmt.Errorf("failed to fetch trending repositories: %w", err)
+		return fmt.Errorf("failed to create trending source: %w", err)
 	}
+	log.Printf("Creating API client (source: %s)...", source.Name())
+	apiClient := api.NewClientWithSource(cfg.API.BaseURL, timeout, source)
 
-	if len(repos) == 0 {
-		log.Println("Warning: No repositories returned from API")
-		return fmt.Errorf("no repositories found")
+	// 打开快照存储（失败不影响发送），dry-run 时不读写快照
+	var snapshotStore *store.Store
+	if cfg.Store.Enabled && !*filterDryRun {
+		snapshotStore, err = store.NewStore(cfg.Store.Dir)
+		if err != nil {
+			log.Printf("Warning: failed to open snapshot store: %v", err)
+			snapshotStore = nil
+		}
 	}
 
-	log.Printf("Successfully fetched %d repositories", len(repos))
+	// 创建通知渠道
+	channels, err := buildChannels(cfg)
+	if err != nil {
+		return err
+	}
 
-	// 格式化数据
-	log.Println("Formatting data...")
-	var formattedContent string
+	// 依次生成所有报告，相同语言和时间范围只抓取一次
+	runner := newReportRunner(cfg, apiClient, channels, snapshotStore, profiles)
+	runner.dryRun = *filterDryRun
+	runner.output = *output
+	runner.noEmail = *noEmail
+	runner.ledger = sendLedger
+	runner.force = *force
 
-	if cfg.Email.UseHTML {
-		htmlFormatter := formatter.NewHTMLFormatter()
-		formattedContent, err = htmlFormatter.Format(repos, cfg.Query.Language, cfg.Query.Period)
-		if err != nil {
-			return fmt.Errorf("failed to format data as HTML: %w", err)
+	results := make([]reportResult, 0, len(profiles))
+	for _, profile := range profiles {
+		log.Printf("========== Report: %s ==========", profile.Name)
+		result := runner.runReport(ctx, profile)
+		if result.Err != nil {
+			log.Printf("Report %s failed: %v", profile.Name, result.Err)
+		} else if result.Recipients > 0 || len(result.Channels) > 0 {
+			log.Printf("Report %s sent successfully", profile.Name)
+		}
+		results = append(results, result)
+	}
+
+	return summarize(results)
+}
+
+// summarize 输出每个报告的结果，有报告失败时返回错误
+func summarize(results []reportResult) error {
+	failed := 0
+	log.Println("========== Run summary ==========")
+	for _, result := range results {
+		for _, warning := range result.Warnings {
+			log.Printf("⚠ %s: %s", result.Name, warning)
+		}
+		if result.Err != nil {
+			failed++
+			log.Printf("✘ %s: %v", result.Name, result.Err)
+			continue
+		}
+		if *filterDryRun {
+			log.Printf("✔ %s: %d repositories kept", result.Name, result.Repos)
+			continue
+		}
+		if result.Skipped > 0 && result.Recipients == 0 && len(result.Channels) == 0 && result.Output == "" {
+			log.Printf("✔ %s: already sent for %s, skipped", result.Name, result.Window)
+			continue
+		}
+		var delivered []string
+		if result.Output != "" {
+			delivered = append(delivered, fmt.Sprintf("written to %s", result.Output))
+		}
+		if result.Recipients > 0 {
+			delivered = append(delivered, fmt.Sprintf("sent to %d recipients", result.Recipients))
+		}
+		if len(result.Channels) > 0 {
+			delivered = append(delivered, fmt.Sprintf("posted to %s", strings.Join(result.Channels, ", ")))
+		}
+		if result.Skipped > 0 {
+			delivered = append(delivered, fmt.Sprintf("skipped %d already sent for %s", result.Skipped, result.Window))
+		}
+		log.Printf("✔ %s: %d repositories %s", result.Name, result.Repos, strings.Join(delivered, " and "))
+	}
+
+	if failed > 0 {
+		errs := make([]error, 0, failed)
+		for _, result := range results {
+			if result.Err != nil {
+				errs = append(errs, result.Err)
+			}
+		}
+		return &runError{errs: errs, total: len(results)}
+	}
+	return nil
+}
+
+// runError 有报告失败，保留各报告的错误供 exitCode 判断失败原因
+type runError struct {
+	errs  []error
+	total int
+}
+
+// Error 如 "2 of 3 reports failed"，各报告的错误已在运行摘要中输出
+func (e *runError) Error() string {
+	return fmt.Sprintf("%d of %d reports failed", len(e.errs), e.total)
+}
+
+// Unwrap 支持 errors.Is 和 errors.As
+func (e *runError) Unwrap() []error {
+	return e.errs
+}
+
+// exitCode 根据错误类型选择退出码，多个报告失败时按退出码常量的顺序取第一个
+func exitCode(err error) int {
+	var runErr *runError
+	if !errors.As(err, &runErr) {
+		return reportExitCode(err)
+	}
+
+	code := exitError
+	for _, err := range runErr.errs {
+		if c := reportExitCode(err); c != exitError && (code == exitError || c < code) {
+			code = c
+		}
+	}
+	return code
+}
+
+// reportExitCode 单个报告失败对应的退出码
+func reportExitCode(err error) int {
+	var netErr net.Error
+	switch {
+	case errors.As(err, new(*notifyError)):
+		return exitNotify
+	case errors.As(err, new(*api.RateLimitError)):
+		return exitRateLimited
+	case errors.As(err, new(*api.NotFoundError)), errors.As(err, new(*api.ClientError)):
+		return exitBadRequest
+	case errors.As(err, new(*api.ServerError)), errors.Is(err, api.ErrCircuitOpen), errors.As(err, &netErr):
+		return exitUnavailable
+	case errors.As(err, new(*api.DecodeError)):
+		return exitDecode
+	default:
+		return exitError
+	}
+}
+
+// buildSource 根据配置创建数据源，配置了备用数据源时自动降级
+func buildSource(cfg *config.Config, timeout time.Duration) (api.TrendingSource, error) {
+	opts := api.SourceOptions{
+		BaseURL: cfg.API.BaseURL,
+		Timeout: timeout,
+		GitHub: api.GitHubOptions{
+			BaseURL:  cfg.API.GitHub.BaseURL,
+			Token:    cfg.API.GitHub.Token,
+			MinStars: cfg.API.GitHub.MinStars,
+		},
+		WebURL:     cfg.API.GitHub.WebURL,
+		DecodeMode: api.DecodeMode(cfg.API.DecodeMode),
+	}
+
+	var source api.TrendingSource
+	if len(cfg.API.Sources) > 0 {
+		// 多个数据源并发请求并合并
+		sources := make([]api.TrendingSource, 0, len(cfg.API.Sources))
+		for _, name := range cfg.API.Sources {
+			s, err := newResilientSource(cfg, strings.TrimSpace(name), opts)
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, s)
+		}
+		if len(sources) == 1 {
+			source = sources[0]
+		} else {
+			multi, err := api.NewMultiSource(sources...)
+			if err != nil {
+				return nil, err
+			}
+			source = multi
 		}
 	} else {
-		textFormatter := formatter.NewTextFormatter()
-		formattedContent, err = textFormatter.Format(repos, cfg.Query.Language, cfg.Query.Period)
+		s, err := newResilientSource(cfg, cfg.API.Source, opts)
 		if err != nil {
-			return fmt.Errorf("failed to format data as text: %w", err)
+			return nil, err
 		}
+		source = s
 	}
 
-	log.Println("Data formatted successfully")
+	if cfg.API.FallbackSource == "" || strings.EqualFold(cfg.API.FallbackSource, source.Name()) {
+		return source, nil
+	}
 
-	// 创建邮件客户端
-	log.Println("Creating email client...")
-	emailClient := email.NewClient(
-		cfg.Email.SMTPHost,
-		cfg.Email.SMTPPort,
-		cfg.Email.Username,
-		cfg.Email.Password,
-		cfg.Email.From,
-	)
+	fallback, err := newResilientSource(cfg, cfg.API.FallbackSource, opts)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Fallback source enabled: %s", fallback.Name())
+	return api.NewFallbackSource(source, fallback), nil
+}
 
-	// 发送邮件
-	log.Printf("Sending email to %d recipients...", len(cfg.Email.To))
+// breakers 每个数据源的熔断器，守护进程的多次运行之间共享熔断状态
+var breakers = make(map[string]*api.CircuitBreaker)
 
-	msg := &email.Message{
-		To:      cfg.Email.To,
-		Subject: cfg.Email.Subject,
-		Body:    formattedContent,
-		IsHTML:  cfg.Email.UseHTML,
+// newResilientSource 创建数据源，临时性失败时按 api.retry 重试，连续失败时按 api.breaker 熔断
+func newResilientSource(cfg *config.Config, name string, opts api.SourceOptions) (api.TrendingSource, error) {
+	source, err := api.NewSource(name, opts)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := emailClient.Send(msg); err != nil {
-		return fmt.Errorf("failed to send email: %w", err)
+	retry := cfg.API.Retry
+	source = api.NewRetrySource(source, api.RetryPolicy{
+		MaxAttempts:     retry.MaxAttempts,
+		InitialInterval: time.Duration(retry.InitialInterval) * time.Second,
+		MaxInterval:     time.Duration(retry.MaxInterval) * time.Second,
+		Multiplier:      retry.Multiplier,
+		MaxElapsed:      time.Duration(retry.MaxElapsed) * time.Second,
+	})
+
+	breaker, ok := breakers[source.Name()]
+	if !ok {
+		breaker = api.NewCircuitBreaker(source.Name(), cfg.API.Breaker.FailureThreshold,
+			time.Duration(cfg.API.Breaker.Cooldown)*time.Second)
+		breakers[source.Name()] = breaker
 	}
+	return breaker.Wrap(source), nil
+}
 
-	return nil
+// buildChannels 根据配置创建通知渠道：启用时的邮件，以及 notifiers 中配置的其他渠道
+func buildChannels(cfg *config.Config) ([]channel, error) {
+	var channels []channel
+	if cfg.Email.Enabled {
+		log.Println("Creating email client...")
+		emailClient, err := email.NewClientWithOptions(
+			cfg.Email.SMTPHost,
+			cfg.Email.SMTPPort,
+			cfg.Email.Username,
+			cfg.Email.Password,
+			cfg.Email.From,
+			email.Options{
+				TLSMode:    cfg.Email.TLSMode,
+				CAFile:     cfg.Email.CAFile,
+				ServerName: cfg.Email.TLSServerName,
+				Auth:       cfg.Email.Auth,
+				Timeout:    time.Duration(cfg.Email.Timeout) * time.Second,
+			},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create email client: %w", err)
+		}
+		channels = append(channels, channel{
+			notifier: notify.NewEmailNotifier(emailClient, cfg.Email.ReplyTo, cfg.Email.Unsubscribe),
+		})
+	}
+
+	// 同一类型配置了多个渠道且未命名时，名称依次为 slack、slack-2……
+	counts := make(map[string]int)
+	for _, nc := range cfg.Notifiers {
+		name := nc.Name
+		if name == "" {
+			kind := strings.ToLower(nc.Type)
+			counts[kind]++
+			name = kind
+			if counts[kind] > 1 {
+				name = fmt.Sprintf("%s-%d", kind, counts[kind])
+			}
+		}
+
+		n, err := notify.NewNotifier(nc.Type, notify.Options{
+			Name:     name,
+			URL:      nc.URL,
+			Secret:   nc.Secret,
+			Headers:  nc.Headers,
+			MaxItems: nc.MaxItems,
+			Timeout:  time.Duration(nc.Timeout) * time.Second,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create notifier: %w", err)
+		}
+
+		ch := channel{notifier: n}
+		if len(nc.Reports) > 0 {
+			ch.reports = make(map[string]bool, len(nc.Reports))
+			for _, report := range nc.Reports {
+				ch.reports[report] = true
+			}
+		}
+		log.Printf("Notifier enabled: %s (%s)", n.Name(), nc.Type)
+		channels = append(channels, ch)
+	}
+	return channels, nil
 }
@@ -0,0 +1,862 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/github-insight-analyze/trending-notifier/internal/config"
+	"github.com/github-insight-analyze/trending-notifier/pkg/api"
+	"github.com/github-insight-analyze/trending-notifier/pkg/filter"
+	"github.com/github-insight-analyze/trending-notifier/pkg/formatter"
+	"github.com/github-insight-analyze/trending-notifier/pkg/ledger"
+	"github.com/github-insight-analyze/trending-notifier/pkg/notify"
+	"github.com/github-insight-analyze/trending-notifier/pkg/rank"
+	"github.com/github-insight-analyze/trending-notifier/pkg/store"
+	"github.com/github-insight-analyze/trending-notifier/pkg/trend"
+)
+
+// reportResult 单个报告的运行结果
+type reportResult struct {
+	Name       string
+	Repos      int
+	Recipients int      // 邮件收件人数量，未发送邮件时为 0
+	Channels   []string // 发送成功的其他通知渠道
+	Output     string   // 报告写入的文件，"-" 表示标准输出
+	Window     string   // 发送账本中的时间窗口
+	Skipped    int      // 该窗口内已经发送过而跳过的收件人和渠道数量
+	Warnings   []string // 报告仍然发出但需要注意的问题，如使用了旧快照
+	Err        error
+}
+
+// channel 通知渠道及其负责的报告
+type channel struct {
+	notifier notify.Notifier
+	reports  map[string]bool // 只发送这些报告，为空表示发送所有报告
+}
+
+// accepts 是否向该渠道发送指定报告
+func (c channel) accepts(report string) bool {
+	return len(c.reports) == 0 || c.reports[report]
+}
+
+// notifyError 通知渠道发送失败，多个渠道的错误信息合并为一行
+type notifyError struct {
+	errs  []error
+	total int // 该报告的通知渠道数量
+}
+
+// Error 只有一个渠道失败时为该渠道的错误，否则如 "2 of 3 channels failed: ...; ..."
+func (e *notifyError) Error() string {
+	if len(e.errs) == 1 {
+		return e.errs[0].Error()
+	}
+	msgs := make([]string, 0, len(e.errs))
+	for _, err := range e.errs {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d of %d channels failed: %s", len(e.errs), e.total, strings.Join(msgs, "; "))
+}
+
+// Unwrap 支持 errors.Is 和 errors.As
+func (e *notifyError) Unwrap() []error {
+	return e.errs
+}
+
+// fetchKey 抓取结果缓存键
+type fetchKey struct {
+	language string
+	period   string
+}
+
+// fetchResult 一次抓取的结果
+type fetchResult struct {
+	repos    []api.Repository
+	source   string              // 实际返回结果的数据源，使用快照时为快照记录的数据源
+	snapshot *store.SnapshotInfo // 本次抓取保存的快照，未保存时为 nil
+	previous []api.Repository    // 上一次快照中的仓库，用于过滤和重新排名后计算排名变化
+	warnings []string            // 需要在运行摘要中提示的问题，如使用了旧快照、跳过了无法解析的数据行
+	err      error
+}
+
+// reportRunner 在一次运行中生成所有报告
+// 语言和时间范围相同的报告（包括多语言报告中的各语言）共用一次抓取，抓取数量取这些报告中最大的 limit
+type reportRunner struct {
+	cfg       *config.Config
+	apiClient *api.Client
+	channels  []channel      // 通知渠道，邮件在最前面
+	store     *store.Store   // 为 nil 表示未启用快照存储
+	dryRun    bool           // 只输出过滤结果，不发送通知
+	output    string         // 报告输出路径，"-" 表示标准输出，为空表示不输出
+	noEmail   bool           // 不发送邮件和其他通知，只输出报告
+	ledger    *ledger.Ledger // 发送账本，为 nil 表示未启用
+	force     bool           // 忽略发送账本，重新发送已经发送过的报告
+	out       io.Writer      // dry-run 和标准输出
+	limits    map[fetchKey]int
+	fetches   map[fetchKey]*fetchResult
+}
+
+// newReportRunner 创建报告运行器
+func newReportRunner(cfg *config.Config, apiClient *api.Client, channels []channel,
+	snapshotStore *store.Store, profiles []config.ReportConfig) *reportRunner {
+	limits := make(map[fetchKey]int)
+	for _, profile := range profiles {
+		for _, language := range profile.Query.Language {
+			key := newFetchKey(language, profile.Query.Period)
+			if profile.Query.Limit > limits[key] {
+				limits[key] = profile.Query.Limit
+			}
+		}
+	}
+
+	return &reportRunner{
+		cfg:       cfg,
+		apiClient: apiClient,
+		channels:  channels,
+		store:     snapshotStore,
+		out:       os.Stdout,
+		limits:    limits,
+		fetches:   make(map[fetchKey]*fetchResult),
+	}
+}
+
+// newFetchKey 语言不区分大小写
+func newFetchKey(language, period string) fetchKey {
+	return fetchKey{
+		language: strings.ToLower(language),
+		period:   period,
+	}
+}
+
+// runReport 生成并发送一个报告
+// 配置了多种语言时逐个语言抓取，部分语言失败时仍发送其余语言的汇总报告
+func (r *reportRunner) runReport(ctx context.Context, profile config.ReportConfig) reportResult {
+	result := reportResult{Name: profile.Name}
+
+	// 该时间窗口内所有收件人都已收到时不再抓取和生成报告
+	if r.ledger != nil && !r.dryRun && !r.noEmail {
+		result.Window = reportWindow(profile, time.Now())
+		if r.output == "" && !r.force {
+			if pending, skipped := r.pending(profile, result.Window); pending == 0 && skipped > 0 {
+				log.Printf("Report %s was already sent for %s, skipping (use -force to send again)", profile.Name, result.Window)
+				result.Skipped = skipped
+				return result
+			}
+		}
+	}
+
+	pipeline, err := newFilterPipeline(profile.Filters)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	strategy, err := rank.New(profile.Ranking.Strategy, profile.Query.Period, profile.Ranking.Weights)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to create ranking strategy: %w", err)
+		return result
+	}
+
+	var sections []formatter.Section
+	var snapshots []*store.SnapshotInfo
+	var sources []string
+	var fetchErr error
+	for _, language := range profile.Query.Language {
+		fetched := r.fetch(ctx, language, profile.Query.Period)
+		result.Warnings = append(result.Warnings, fetched.warnings...)
+		if fetched.err != nil {
+			if len(profile.Query.Language) > 1 {
+				log.Printf("Warning: skipping language %s: %v", language, fetched.err)
+				result.Warnings = append(result.Warnings, fmt.Sprintf("language %s skipped: %v", language, fetched.err))
+			}
+			if fetchErr == nil {
+				fetchErr = fetched.err
+			}
+			continue
+		}
+
+		// 按规则过滤并重新排名
+		repos, dropped := processRepos(fetched.repos, pipeline, strategy)
+		if len(dropped) > 0 {
+			log.Printf("Filtered out %d of %d repositories (language: %s)", len(dropped), len(repos)+len(dropped), language)
+		}
+		repos = truncate(repos, profile.Query.Limit)
+		result.Repos += len(repos)
+
+		// 排名变化与经过同样过滤和排名的上一次快照对比
+		if fetched.previous != nil {
+			previous, _ := processRepos(fetched.previous, pipeline, strategy)
+			trend.ApplyRankChanges(repos, previous)
+		}
+
+		if r.dryRun {
+			printFilterResult(r.out, profile, language, repos, dropped)
+			continue
+		}
+
+		// 与该报告上一次发送的内容对比
+		var reportDiff *trend.ReportDiff
+		if r.store != nil {
+			reportDiff, err = buildReportDiff(r.store, profile, language, pipeline, strategy, repos)
+			if err != nil {
+				log.Printf("Warning: failed to compare with last report: %v", err)
+			}
+		}
+
+		sections = append(sections, formatter.Section{Language: language, Repos: repos, Diff: reportDiff})
+		sources = appendSource(sources, fetched.source)
+		if fetched.snapshot != nil {
+			snapshots = append(snapshots, fetched.snapshot)
+		}
+	}
+	if r.dryRun {
+		result.Err = fetchErr
+		return result
+	}
+	if len(sections) == 0 {
+		result.Err = fetchErr
+		return result
+	}
+
+	// 格式化数据，数据源为实际返回结果的数据源，多语言报告中各语言的数据源可能不同
+	source := strings.Join(sources, ", ")
+	log.Println("Formatting data...")
+	content, err := formatReport(profile, source, strategy, sections)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	log.Println("Data formatted successfully")
+
+	// 写入文件或标准输出
+	if r.output != "" {
+		result.Output, err = r.writeOutput(profile, content)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+	}
+
+	// 合并到 Atom/RSS feed（失败不影响发送）
+	if r.cfg.Feed.Enabled {
+		if err := r.writeFeed(profile, sections); err != nil {
+			log.Printf("Warning: failed to write feed: %v", err)
+		}
+	}
+
+	if r.noEmail {
+		return result
+	}
+
+	subject, err := renderSubject(profile, result.Repos, time.Now())
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	// 发送到各通知渠道，HTML 报告同时附带纯文本版本
+	n := &notify.Notification{
+		Report:  profile.Name,
+		Subject: subject,
+		Body:    content,
+		IsHTML:  profile.Format == "html",
+		To:      profile.To,
+		Cc:      profile.Cc,
+		Bcc:     profile.Bcc,
+	}
+	if n.IsHTML {
+		n.TextBody, err = formatTextAlternative(profile, source, strategy, sections)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+	}
+	exporter := &formatter.JSONFormatter{Source: source, Ranking: strategy.String()}
+	n.Document = exporter.Document(newDigest(profile, strategy, sections))
+
+	// 某个渠道失败不影响其他渠道，有任一渠道发送成功即视为已发送
+	var errs []error
+	total := 0
+	for _, ch := range r.channels {
+		if !ch.accepts(profile.Name) {
+			continue
+		}
+		if _, isEmail := ch.notifier.(*notify.EmailNotifier); isEmail {
+			sent, skipped, err := r.sendEmail(ctx, ch.notifier, n, result.Window)
+			result.Recipients += sent
+			result.Skipped += skipped
+			if err != nil {
+				total++
+				errs = append(errs, err)
+			} else if sent > 0 {
+				total++
+			}
+			continue
+		}
+
+		recipient := ledger.ChannelRecipient(ch.notifier.Name())
+		if r.alreadySent(profile.Name, result.Window, recipient) {
+			log.Printf("Report %s was already posted to %s for %s, skipping", profile.Name, ch.notifier.Name(), result.Window)
+			result.Skipped++
+			continue
+		}
+		total++
+		log.Printf("Sending report to %s...", ch.notifier.Name())
+		if err := ch.notifier.Notify(ctx, n); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		result.Channels = append(result.Channels, ch.notifier.Name())
+		r.record(n, result.Window, recipient)
+	}
+	if len(errs) > 0 {
+		result.Err = &notifyError{errs: errs, total: total}
+	}
+	if result.Recipients == 0 && len(result.Channels) == 0 {
+		return result
+	}
+
+	// 记录本次发送的快照，下次报告据此生成差异
+	if r.store != nil {
+		for _, snapshot := range snapshots {
+			if err := r.store.MarkSent(snapshot.ID, profile.Name); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		}
+	}
+
+	return result
+}
+
+// sendEmail 发送邮件，跳过在该时间窗口内已经收到报告的收件人，返回发送和跳过的收件人数量
+// 邮件必须有 To 收件人：To 都已收到时由 Cc 补位，只剩 Bcc 时逐个单独发送，不暴露 Bcc 地址
+func (r *reportRunner) sendEmail(ctx context.Context, notifier notify.Notifier, n *notify.Notification,
+	window string) (int, int, error) {
+	to := r.unsent(n.Report, window, n.To)
+	cc := r.unsent(n.Report, window, n.Cc)
+	bcc := r.unsent(n.Report, window, n.Bcc)
+	skipped := len(n.To) + len(n.Cc) + len(n.Bcc) - len(to) - len(cc) - len(bcc)
+	if len(to)+len(cc)+len(bcc) == 0 {
+		log.Printf("Report %s was already emailed to all %d recipients for %s, skipping", n.Report, skipped, window)
+		return 0, skipped, nil
+	}
+	if skipped > 0 {
+		log.Printf("Skipping %d recipients who already received report %s for %s", skipped, n.Report, window)
+	}
+
+	var messages []*notify.Notification
+	switch {
+	case len(to) > 0:
+		messages = append(messages, withRecipients(n, to, cc, bcc))
+	case len(cc) > 0:
+		messages = append(messages, withRecipients(n, cc, nil, bcc))
+	default:
+		for _, addr := range bcc {
+			messages = append(messages, withRecipients(n, []string{addr}, nil, nil))
+		}
+	}
+
+	sent := 0
+	for _, msg := range messages {
+		recipients := append(append(append([]string{}, msg.To...), msg.Cc...), msg.Bcc...)
+		log.Printf("Sending email to %d recipients...", len(recipients))
+		if err := notifier.Notify(ctx, msg); err != nil {
+			return sent, skipped, err
+		}
+		sent += len(recipients)
+		r.record(msg, window, recipients...)
+	}
+	return sent, skipped, nil
+}
+
+// withRecipients 复制通知并替换收件人
+func withRecipients(n *notify.Notification, to, cc, bcc []string) *notify.Notification {
+	msg := *n
+	msg.To, msg.Cc, msg.Bcc = to, cc, bcc
+	return &msg
+}
+
+// pending 统计报告在该时间窗口内还需要发送和已经发送过的收件人（邮件地址和其他通知渠道）数量
+func (r *reportRunner) pending(profile config.ReportConfig, window string) (int, int) {
+	pending, skipped := 0, 0
+	for _, ch := range r.channels {
+		if !ch.accepts(profile.Name) {
+			continue
+		}
+		var recipients []string
+		if _, isEmail := ch.notifier.(*notify.EmailNotifier); isEmail {
+			recipients = append(append(append(recipients, profile.To...), profile.Cc...), profile.Bcc...)
+		} else {
+			recipients = []string{ledger.ChannelRecipient(ch.notifier.Name())}
+		}
+		unsent := len(r.unsent(profile.Name, window, recipients))
+		pending += unsent
+		skipped += len(recipients) - unsent
+	}
+	return pending, skipped
+}
+
+// unsent 过滤掉在该时间窗口内已经收到报告的收件人，未启用账本或使用 -force 时原样返回
+func (r *reportRunner) unsent(report, window string, recipients []string) []string {
+	if r.ledger == nil || r.force {
+		return recipients
+	}
+	var result []string
+	for _, recipient := range recipients {
+		if _, ok := r.ledger.Sent(report, window, recipient); !ok {
+			result = append(result, recipient)
+		}
+	}
+	return result
+}
+
+// alreadySent 报告在该时间窗口内是否已经发送给收件人，使用 -force 时总是返回 false
+func (r *reportRunner) alreadySent(report, window, recipient string) bool {
+	return len(r.unsent(report, window, []string{recipient})) == 0
+}
+
+// record 在发送账本中记录已发送的收件人，写入失败时只打印警告（报告已经发出）
+func (r *reportRunner) record(n *notify.Notification, window string, recipients ...string) {
+	if r.ledger == nil {
+		return
+	}
+	now := time.Now()
+	entries := make([]ledger.Entry, 0, len(recipients))
+	for _, recipient := range recipients {
+		entries = append(entries, ledger.Entry{
+			Report:    n.Report,
+			Window:    window,
+			Recipient: recipient,
+			SentAt:    now,
+			Subject:   n.Subject,
+			Forced:    r.force,
+		})
+	}
+	if err := r.ledger.Record(entries...); err != nil {
+		log.Printf("Warning: failed to record delivery in ledger: %v", err)
+	}
+}
+
+// reportWindow 报告在发送账本中的时间窗口，按报告的时区（timezone）划分
+func reportWindow(profile config.ReportConfig, now time.Time) string {
+	if profile.Timezone != "" {
+		if location, err := time.LoadLocation(profile.Timezone); err == nil {
+			now = now.In(location)
+		}
+	}
+	return ledger.Window(profile.Query.Period, now)
+}
+
+// fetch 获取 trending 仓库，同一语言和时间范围只请求一次
+// 每次实际抓取后与上一次快照对比并保存新快照；返回的结果在报告之间共享，不能修改
+func (r *reportRunner) fetch(ctx context.Context, language, period string) *fetchResult {
+	key := newFetchKey(language, period)
+	if cached, ok := r.fetches[key]; ok {
+		log.Printf("Reusing fetched repositories (language: %s, period: %s)", language, period)
+		return cached
+	}
+
+	log.Printf("Fetching trending repositories (language: %s, period: %s, limit: %d)...",
+		language, period, r.limits[key])
+
+	var parseWarnings api.ParseWarnings
+	var origin api.Origin
+	fetchCtx := api.WithOrigin(api.WithParseWarnings(ctx, &parseWarnings), &origin)
+	repos, err := r.apiClient.GetTrendingRepos(fetchCtx, language, period, r.limits[key])
+	if err != nil {
+		err = fmt.Errorf("failed to fetch trending repositories: %w", err)
+		if snapshot := r.fallbackSnapshot(ctx, language, period); snapshot != nil {
+			fetchedAt := snapshot.FetchedAt.Local().Format("2006-01-02 15:04")
+			log.Printf("Warning: %v", err)
+			log.Printf("Using last good snapshot %s fetched at %s (%d repositories)", snapshot.ID, fetchedAt, len(snapshot.Repos))
+			info := snapshot.SnapshotInfo
+			source := info.Source
+			if source == "" {
+				source = r.apiClient.Source().Name()
+			}
+			result := &fetchResult{
+				repos:    snapshot.Repos,
+				source:   source,
+				snapshot: &info,
+				warnings: []string{fmt.Sprintf("%s: sources unavailable, used snapshot fetched at %s", language, fetchedAt)},
+			}
+			r.fetches[key] = result
+			return result
+		}
+	} else if len(repos) == 0 {
+		log.Println("Warning: No repositories returned from API")
+		err = fmt.Errorf("no repositories found")
+	}
+	result := &fetchResult{repos: repos, err: err}
+	for _, warning := range parseWarnings.List() {
+		result.warnings = append(result.warnings, fmt.Sprintf("%s: %s", language, warning))
+	}
+	r.fetches[key] = result
+	if err != nil {
+		return result
+	}
+
+	source := origin.Source()
+	if source == "" {
+		source = r.apiClient.Source().Name()
+	}
+	result.source = source
+	log.Printf("Successfully fetched %d repositories from %s", len(repos), source)
+
+	// 与同一数据源的历史快照对比并保存本次快照（失败不影响发送）
+	if r.store != nil {
+		result.previous, err = applyDeltas(r.store, language, period, source, repos)
+		if err != nil {
+			log.Printf("Warning: failed to compute deltas: %v", err)
+		}
+		result.snapshot, err = saveSnapshot(r.store, r.cfg, language, period, source, repos, time.Now())
+		if err != nil {
+			log.Printf("Warning: failed to save snapshot: %v", err)
+		}
+	}
+
+	return result
+}
+
+// fallbackSnapshot 所有数据源都失败时加载该语言和时间范围最近一次成功抓取的快照
+// 未启用 snapshot_fallback、没有快照或快照超过 max_age 时返回 nil
+func (r *reportRunner) fallbackSnapshot(ctx context.Context, language, period string) *store.Snapshot {
+	if r.store == nil || !r.cfg.API.SnapshotFallback.Enabled || ctx.Err() != nil {
+		return nil
+	}
+
+	snapshot, err := r.store.Latest(language, period)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			log.Printf("Warning: failed to load fallback snapshot: %v", err)
+		}
+		return nil
+	}
+	if maxAge := time.Duration(r.cfg.API.SnapshotFallback.MaxAge) * time.Hour; maxAge > 0 {
+		if age := time.Since(snapshot.FetchedAt); age > maxAge {
+			log.Printf("Latest snapshot %s is %s old, older than snapshot_fallback max_age (%s), not using it",
+				snapshot.ID, age.Round(time.Minute), maxAge)
+			return nil
+		}
+	}
+	return snapshot
+}
+
+// appendSource 添加数据源，已存在时不重复添加
+func appendSource(sources []string, source string) []string {
+	for _, s := range sources {
+		if s == source {
+			return sources
+		}
+	}
+	return append(sources, source)
+}
+
+// processRepos 按规则过滤并按排名策略重新排名，返回新的切片，不修改传入的仓库列表
+func processRepos(repos []api.Repository, pipeline *filter.Pipeline,
+	strategy *rank.Strategy) ([]api.Repository, []filter.Dropped) {
+	kept, dropped := pipeline.Apply(repos)
+	kept = append([]api.Repository(nil), kept...)
+	strategy.Apply(kept)
+	return kept, dropped
+}
+
+// writeOutput 将报告写入输出文件，路径中的 {name} 替换为报告名称
+func (r *reportRunner) writeOutput(profile config.ReportConfig, content string) (string, error) {
+	if r.output == "-" {
+		if !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		if _, err := io.WriteString(r.out, content); err != nil {
+			return "", fmt.Errorf("failed to write report to stdout: %w", err)
+		}
+		return r.output, nil
+	}
+
+	path := strings.ReplaceAll(r.output, "{name}", profile.Name)
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return "", fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write report: %w", err)
+	}
+	log.Printf("Report written to %s", path)
+	return path, nil
+}
+
+// writeFeed 将报告中的仓库合并到 feed 文件，路径中的 {name} 替换为报告名称
+// 之前的 feed 无法解析时重新生成
+func (r *reportRunner) writeFeed(profile config.ReportConfig, sections []formatter.Section) error {
+	feedCfg := r.cfg.Feed
+	path := strings.ReplaceAll(feedCfg.Path, "{name}", profile.Name)
+
+	previous, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read feed: %w", err)
+	}
+
+	f := &formatter.FeedFormatter{
+		Kind:     feedCfg.Format,
+		Title:    feedCfg.Title,
+		Link:     feedCfg.Link,
+		MaxItems: feedCfg.MaxItems,
+		Previous: previous,
+	}
+	digest := &formatter.Digest{Period: profile.Query.Period, Sections: sections}
+	content, err := f.FormatDigest(digest)
+	if err != nil && len(previous) > 0 {
+		log.Printf("Warning: %v, starting a new feed", err)
+		f.Previous = nil
+		content, err = f.FormatDigest(digest)
+	}
+	if err != nil {
+		return err
+	}
+
+	// 先写临时文件再重命名，避免中途失败留下不完整的 feed
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create feed directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".feed-*")
+	if err != nil {
+		return fmt.Errorf("failed to create feed file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write feed: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write feed: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("failed to write feed: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to save feed: %w", err)
+	}
+
+	log.Printf("Feed updated: %s", path)
+	return nil
+}
+
+// truncate 截取前 limit 个仓库
+func truncate(repos []api.Repository, limit int) []api.Repository {
+	if limit > 0 && len(repos) > limit {
+		return repos[:limit]
+	}
+	return repos
+}
+
+// reportFormatter 报告格式化器，同时支持单语言报告和多语言汇总报告
+type reportFormatter interface {
+	formatter.Formatter
+	formatter.DigestFormatter
+}
+
+// formatReport 按报告格式生成内容
+// 只有一种语言时生成普通报告，多种语言时生成带目录的汇总报告
+func formatReport(profile config.ReportConfig, source string, strategy *rank.Strategy,
+	sections []formatter.Section) (string, error) {
+	var f reportFormatter
+	switch profile.Format {
+	case "json":
+		f = &formatter.JSONFormatter{Source: source, Ranking: strategy.String()}
+	case "csv":
+		f = &formatter.CSVFormatter{Source: source, Ranking: strategy.String()}
+	case "html":
+		f = &formatter.HTMLFormatter{Diff: sections[0].Diff, Ranking: strategy.String(), TemplatePath: profile.TemplatePath}
+	case "markdown":
+		f = &formatter.MarkdownFormatter{Diff: sections[0].Diff, Ranking: strategy.String(), Compact: profile.Compact}
+	default:
+		f = &formatter.TextFormatter{Diff: sections[0].Diff, Ranking: strategy.String(), TemplatePath: profile.TemplatePath}
+	}
+
+	var content string
+	var err error
+	if len(profile.Query.Language) > 1 {
+		content, err = f.FormatDigest(newDigest(profile, strategy, sections))
+	} else {
+		content, err = f.Format(sections[0].Repos, sections[0].Language, profile.Query.Period)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to format data as %s: %w", profile.Format, err)
+	}
+	return content, nil
+}
+
+// formatTextAlternative 生成 HTML 邮件的纯文本版本，始终使用内置纯文本模板
+func formatTextAlternative(profile config.ReportConfig, source string, strategy *rank.Strategy,
+	sections []formatter.Section) (string, error) {
+	profile.Format = "text"
+	profile.TemplatePath = ""
+	return formatReport(profile, source, strategy, sections)
+}
+
+// newDigest 组装多语言汇总报告，配置了 overall_top 时附带跨语言总榜
+// 总榜默认按 star 数排名，选择了排名策略时使用同一策略
+func newDigest(profile config.ReportConfig, strategy *rank.Strategy, sections []formatter.Section) *formatter.Digest {
+	digest := &formatter.Digest{
+		Period:   profile.Query.Period,
+		Sections: sections,
+	}
+	if profile.Query.OverallTop > 0 {
+		lists := make([][]api.Repository, 0, len(sections))
+		for _, section := range sections {
+			lists = append(lists, section.Repos)
+		}
+		if strategy.IsSource() {
+			digest.Overall = trend.TopAcross(lists, profile.Query.OverallTop)
+		} else {
+			overall := trend.TopAcross(lists, 0)
+			strategy.Apply(overall)
+			digest.Overall = truncate(overall, profile.Query.OverallTop)
+		}
+	}
+	return digest
+}
+
+// subjectData 邮件主题模板可使用的数据
+type subjectData struct {
+	Name     string
+	Language string
+	Period   string
+	Date     string
+	Count    int
+}
+
+// renderSubject 渲染邮件主题模板
+func renderSubject(profile config.ReportConfig, count int, now time.Time) (string, error) {
+	tmpl, err := template.New("subject").Parse(profile.Subject)
+	if err != nil {
+		return "", fmt.Errorf("invalid subject template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, subjectData{
+		Name:     profile.Name,
+		Language: profile.Query.Language.String(),
+		Period:   profile.Query.Period,
+		Date:     now.Format("2006-01-02"),
+		Count:    count,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to render subject: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// buildReportDiff 与该报告上一次发送的内容对比，从未发送过时返回 nil
+// 上一次的内容经过同样的过滤和排名，避免被过滤的仓库出现在差异中
+func buildReportDiff(snapshotStore *store.Store, profile config.ReportConfig, language string,
+	pipeline *filter.Pipeline, strategy *rank.Strategy, repos []api.Repository) (*trend.ReportDiff, error) {
+	lastSent, err := snapshotStore.LastSent(language, profile.Query.Period, profile.Name)
+	if errors.Is(err, store.ErrNotFound) {
+		log.Println("No previously sent report found, skipping diff section")
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	previous, _ := processRepos(lastSent.Repos, pipeline, strategy)
+	diff := trend.Diff(repos, truncate(previous, profile.Query.Limit), lastSent.FetchedAt)
+	log.Printf("Compared with last report %s: %d new, %d still trending, %d dropped",
+		lastSent.ID, len(diff.New), len(diff.Still), len(diff.Dropped))
+	return diff, nil
+}
+
+// newFilterPipeline 根据报告的过滤规则创建过滤流水线
+func newFilterPipeline(filters []config.FilterConfig) (*filter.Pipeline, error) {
+	rules := make([]filter.Rule, 0, len(filters))
+	for _, f := range filters {
+		rules = append(rules, filter.Rule{
+			Name:                f.Name,
+			Action:              f.Action,
+			Reason:              f.Reason,
+			Owners:              f.Owners,
+			NameRegex:           f.NameRegex,
+			DescriptionKeywords: f.DescriptionKeywords,
+			Languages:           f.Languages,
+			Topics:              f.Topics,
+			MinStars:            f.MinStars,
+			MinForks:            f.MinForks,
+			MinPushes:           f.MinPushes,
+			MinPullRequests:     f.MinPullRequests,
+			Archived:            f.Archived,
+			EmptyDescription:    f.EmptyDescription,
+		})
+	}
+
+	pipeline, err := filter.New(rules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create filters: %w", err)
+	}
+	return pipeline, nil
+}
+
+// printFilterResult 输出 dry-run 的过滤结果：保留的仓库以及被过滤的仓库和原因
+func printFilterResult(w io.Writer, profile config.ReportConfig, language string,
+	kept []api.Repository, dropped []filter.Dropped) {
+	fmt.Fprintf(w, "Report %s (language: %s, period: %s): %d kept, %d filtered\n",
+		profile.Name, language, profile.Query.Period, len(kept), len(dropped))
+	for i, repo := range kept {
+		fmt.Fprintf(w, "  ✔ #%d %s\n", i+1, repo.RepoName)
+	}
+	for _, d := range dropped {
+		fmt.Fprintf(w, "  ✘ %s: %s [%s]\n", d.Repo.RepoName, d.Reason, d.Rule)
+	}
+	fmt.Fprintln(w)
+}
+
+// applyDeltas 与同语言、同时间范围、同一数据源的上一次快照对比，填充增量和排名变化，返回上一次快照中的仓库
+// 不同数据源的 star 数和排名含义不同，没有同一数据源的快照时不计算增量
+func applyDeltas(snapshotStore *store.Store, language, period, source string, repos []api.Repository) ([]api.Repository, error) {
+	previous, err := snapshotStore.LatestFrom(language, period, source)
+	if errors.Is(err, store.ErrNotFound) {
+		log.Printf("No previous snapshot from %s found, skipping delta computation", source)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	stats := trend.ApplyDeltas(repos, previous.Repos)
+	log.Printf("Compared with snapshot %s: %d matched, %d new", previous.ID, stats.Matched, stats.New)
+	return previous.Repos, nil
+}
+
+// saveSnapshot 保存本次抓取结果，并按保留天数清理旧快照
+func saveSnapshot(snapshotStore *store.Store, cfg *config.Config, language, period, source string,
+	repos []api.Repository, fetchedAt time.Time) (*store.SnapshotInfo, error) {
+	info, err := snapshotStore.Save(language, period, source, fetchedAt, repos)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Snapshot saved: %s (%d repositories)", info.ID, info.Count)
+
+	if cfg.Store.RetentionDays > 0 {
+		before := fetchedAt.AddDate(0, 0, -cfg.Store.RetentionDays)
+		// 每个语言/时间范围至少保留最近一次快照，供下次对比使用
+		removed, err := snapshotStore.Prune(before, 1)
+		if err != nil {
+			return info, err
+		}
+		if removed > 0 {
+			log.Printf("Pruned %d snapshots older than %d days", removed, cfg.Store.RetentionDays)
+		}
+	}
+
+	return info, nil
+}
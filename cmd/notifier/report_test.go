@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github-insight-analyze/trending-notifier/internal/config"
+	"github.com/github-insight-analyze/trending-notifier/pkg/api"
+	"github.com/github-insight-analyze/trending-notifier/pkg/email"
+	"github.com/github-insight-analyze/trending-notifier/pkg/formatter"
+	"github.com/github-insight-analyze/trending-notifier/pkg/ledger"
+	"github.com/github-insight-analyze/trending-notifier/pkg/notify"
+)
+
+// stubSource 按语言返回固定结果的数据源，failing 中的语言返回错误
+type stubSource struct {
+	name    string
+	failing map[string]bool
+}
+
+func (s *stubSource) Name() string {
+	return s.name
+}
+
+func (s *stubSource) FetchTrending(ctx context.Context, language string, period string, limit int) ([]api.Repository, error) {
+	if s.failing[language] {
+		return nil, &api.ServerError{StatusCode: 502}
+	}
+	return []api.Repository{{FullName: language + "/" + s.name, Stars: 10}}, nil
+}
+
+// captureNotifier 记录收到的通知
+type captureNotifier struct {
+	got []*notify.Notification
+}
+
+func (c *captureNotifier) Name() string {
+	return "capture"
+}
+
+func (c *captureNotifier) Notify(ctx context.Context, n *notify.Notification) error {
+	c.got = append(c.got, n)
+	return nil
+}
+
+// newTestRunner 使用 source 抓取，报告输出到返回的 buffer，通知发送到返回的 captureNotifier
+func newTestRunner(source api.TrendingSource, profile config.ReportConfig) (*reportRunner, *bytes.Buffer, *captureNotifier) {
+	capture := &captureNotifier{}
+	client := api.NewClientWithSource("http://127.0.0.1:0", time.Second, source)
+	runner := newReportRunner(&config.Config{}, client, []channel{{notifier: capture}}, nil, []config.ReportConfig{profile})
+	var out bytes.Buffer
+	runner.out = &out
+	runner.output = "-"
+	return runner, &out, capture
+}
+
+func TestRunReportUsesAnsweringSource(t *testing.T) {
+	tests := []struct {
+		name      string
+		languages config.Languages
+		failing   map[string]bool
+		want      string
+	}{
+		{"primary answers", config.Languages{"go"}, nil, "primary"},
+		{"fallback answers", config.Languages{"go"}, map[string]bool{"go": true}, "backup"},
+		{"mixed digest", config.Languages{"go", "rust", "zig"}, map[string]bool{"go": true}, "backup, primary"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := api.NewFallbackSource(&stubSource{name: "primary", failing: tt.failing}, &stubSource{name: "backup"})
+			profile := config.ReportConfig{
+				Name:    "test",
+				Query:   config.QueryConfig{Language: tt.languages, Period: "daily", Limit: 10},
+				Subject: "Trending",
+				Format:  "json",
+			}
+			runner, out, capture := newTestRunner(source, profile)
+
+			result := runner.runReport(context.Background(), profile)
+			if result.Err != nil {
+				t.Fatal(result.Err)
+			}
+
+			var doc formatter.ExportDocument
+			if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+				t.Fatalf("invalid JSON report: %v\n%s", err, out.String())
+			}
+			if doc.Source != tt.want {
+				t.Errorf("exported source = %q, want %q", doc.Source, tt.want)
+			}
+			if len(capture.got) != 1 || capture.got[0].Document.Source != tt.want {
+				t.Errorf("notification source = %+v, want %q", capture.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunReportDigestWarnsSkippedLanguage(t *testing.T) {
+	source := &stubSource{name: "primary", failing: map[string]bool{"rust": true}}
+	profile := config.ReportConfig{
+		Name:    "digest",
+		Query:   config.QueryConfig{Language: config.Languages{"go", "rust"}, Period: "daily", Limit: 10},
+		Subject: "Trending",
+		Format:  "json",
+	}
+	runner, _, capture := newTestRunner(source, profile)
+
+	result := runner.runReport(context.Background(), profile)
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	if len(capture.got) != 1 {
+		t.Fatalf("sent %d notifications, want the digest to still be sent", len(capture.got))
+	}
+	if len(result.Warnings) != 1 {
+		t.Fatalf("warnings = %v, want one for the skipped language", result.Warnings)
+	}
+	if w := result.Warnings[0]; !strings.HasPrefix(w, "language rust skipped: ") || !strings.Contains(w, "502") {
+		t.Errorf("warning = %q, want it to name the skipped language and its error", w)
+	}
+}
+
+func TestReportWindow(t *testing.T) {
+	utc := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name     string
+		timezone string
+		period   string
+		now      time.Time
+		want     string
+	}{
+		{"shanghai before midnight", "Asia/Shanghai", "daily", utc(5, 4, 15, 59), "2026-05-04"},
+		{"shanghai after midnight", "Asia/Shanghai", "daily", utc(5, 4, 16, 0), "2026-05-05"},
+		{"new york still yesterday", "America/New_York", "daily", utc(5, 5, 3, 59), "2026-05-04"},
+		{"new york after midnight", "America/New_York", "daily", utc(5, 5, 4, 0), "2026-05-05"},
+		{"shanghai new week", "Asia/Shanghai", "weekly", utc(5, 10, 16, 0), "2026-W20"},
+		{"utc same instant", "UTC", "weekly", utc(5, 10, 16, 0), "2026-W19"},
+		{"shanghai new month", "Asia/Shanghai", "monthly", utc(5, 31, 16, 0), "2026-06"},
+		{"no timezone uses now", "", "daily", utc(5, 4, 16, 0), "2026-05-04"},
+		{"invalid timezone uses now", "Mars/Olympus", "daily", utc(5, 4, 16, 0), "2026-05-04"},
+	}
+
+	for _, tt := range tests {
+		profile := config.ReportConfig{Timezone: tt.timezone, Query: config.QueryConfig{Period: tt.period}}
+		if got := reportWindow(profile, tt.now); got != tt.want {
+			t.Errorf("%s: reportWindow = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReportLedgerAcrossWindows(t *testing.T) {
+	l, err := ledger.Open(filepath.Join(t.TempDir(), "ledger.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile := config.ReportConfig{
+		Name:     "daily",
+		Timezone: "Asia/Shanghai",
+		Query:    config.QueryConfig{Period: "daily"},
+		To:       []string{"Alice <alice@example.com>"},
+		Bcc:      []string{"bob@example.com"},
+	}
+	emailNotifier := notify.NewEmailNotifier(email.NewClient("smtp.example.com", 587, "", "", "bot@example.com"), nil, nil)
+	runner := newReportRunner(&config.Config{}, nil, []channel{{notifier: emailNotifier}, {notifier: &captureNotifier{}}}, nil, nil)
+	runner.ledger = l
+
+	// 上海时间 23:59 发送，00:00 属于新的窗口
+	before := reportWindow(profile, time.Date(2026, 5, 4, 15, 59, 0, 0, time.UTC))
+	after := reportWindow(profile, time.Date(2026, 5, 4, 16, 0, 0, 0, time.UTC))
+	n := &notify.Notification{Report: profile.Name, Subject: "Trending"}
+	runner.record(n, before, "alice@example.com", ledger.ChannelRecipient("capture"))
+
+	tests := []struct {
+		name        string
+		window      string
+		force       bool
+		wantPending int
+		wantSkipped int
+	}{
+		{"same window", before, false, 1, 2}, // 只剩 Bcc 未发送
+		{"next window", after, false, 3, 0},
+		{"force", before, true, 3, 0},
+	}
+	for _, tt := range tests {
+		runner.force = tt.force
+		pending, skipped := runner.pending(profile, tt.window)
+		if pending != tt.wantPending || skipped != tt.wantSkipped {
+			t.Errorf("%s: pending, skipped = %d, %d, want %d, %d", tt.name, pending, skipped, tt.wantPending, tt.wantSkipped)
+		}
+	}
+}
+
+func TestRunReportLedgerSkipsAndForce(t *testing.T) {
+	l, err := ledger.Open(filepath.Join(t.TempDir(), "ledger.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile := config.ReportConfig{
+		Name:     "daily",
+		Timezone: "Asia/Shanghai",
+		Query:    config.QueryConfig{Language: config.Languages{"go"}, Period: "daily", Limit: 10},
+		Subject:  "Trending",
+		Format:   "text",
+	}
+	runner, _, capture := newTestRunner(&stubSource{name: "primary"}, profile)
+	runner.output = ""
+	runner.ledger = l
+
+	first := runner.runReport(context.Background(), profile)
+	if first.Err != nil || len(capture.got) != 1 {
+		t.Fatalf("first run: err = %v, sent %d, want one notification", first.Err, len(capture.got))
+	}
+	if want := reportWindow(profile, time.Now()); first.Window != want {
+		t.Errorf("window = %q, want %q", first.Window, want)
+	}
+
+	second := runner.runReport(context.Background(), profile)
+	if second.Err != nil || len(capture.got) != 1 || second.Skipped != 1 {
+		t.Errorf("second run: err = %v, sent %d, skipped %d, want it skipped", second.Err, len(capture.got)-1, second.Skipped)
+	}
+
+	runner.force = true
+	forced := runner.runReport(context.Background(), profile)
+	if forced.Err != nil || len(capture.got) != 2 {
+		t.Fatalf("forced run: err = %v, sent %d, want the report sent again", forced.Err, len(capture.got)-1)
+	}
+	entries := l.List(ledger.Filter{Report: profile.Name, Window: first.Window})
+	if len(entries) != 2 || !entries[0].Forced || entries[1].Forced {
+		t.Errorf("ledger entries = %+v, want the forced entry after the first one", entries)
+	}
+}
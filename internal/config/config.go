@@ -3,63 +3,369 @@ package config
 import (
 	"fmt"
 	"log"
+	"net/mail"
+	"net/url"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
+	"github.com/github-insight-analyze/trending-notifier/pkg/schedule"
 	"github.com/joho/godotenv"
 	"gopkg.in/yaml.v3"
 )
 
 // Config 应用程序配置
 type Config struct {
-	API   APIConfig   `yaml:"api"`
-	Email EmailConfig `yaml:"email"`
-	Query QueryConfig `yaml:"query"`
+	API       APIConfig        `yaml:"api"`
+	Email     EmailConfig      `yaml:"email"`
+	Query     QueryConfig      `yaml:"query"`
+	Store     StoreConfig      `yaml:"store"`
+	Feed      FeedConfig       `yaml:"feed"`
+	Notifiers []NotifierConfig `yaml:"notifiers"` // 邮件之外的通知渠道，可同时启用多个
+	Schedule  ScheduleConfig   `yaml:"schedule"`  // 守护进程模式（-daemon）的定时配置
+	Ledger    LedgerConfig     `yaml:"ledger"`    // 发送账本，防止重复发送
+	Filters   []FilterConfig   `yaml:"filters"`   // 过滤规则，对所有报告生效
+	Ranking   RankingConfig    `yaml:"ranking"`   // 排名策略，对所有报告生效
+	Reports   []ReportConfig   `yaml:"reports"`   // 报告列表，为空时使用 query 和 email 生成一个默认报告
 }
 
 // APIConfig GitHub API配置
 type APIConfig struct {
-	BaseURL string `yaml:"base_url"`
-	Timeout int    `yaml:"timeout"` // 超时时间（秒）
+	Source         string       `yaml:"source"`          // 数据源，如 "ossinsight", "github", "github_trending"
+	Sources        []string     `yaml:"sources"`         // 同时请求并合并的多个数据源，设置后忽略 source
+	FallbackSource string       `yaml:"fallback_source"` // 主数据源失败时使用的备用数据源，为空表示不启用
+	BaseURL        string       `yaml:"base_url"`        // OSSInsight 地址，可指向镜像、代理或本地测试服务
+	Timeout        int          `yaml:"timeout"`         // 超时时间（秒）
+	DecodeMode     string       `yaml:"decode_mode"`     // OSSInsight 和 GitHub Search 响应中有无法解析的行时：strict 直接失败，lenient 跳过并在运行摘要中警告
+	GitHub         GitHubConfig `yaml:"github"`
+
+	Retry            RetryConfig            `yaml:"retry"`             // 临时性失败（网络错误、429、5xx）的重试策略
+	Breaker          BreakerConfig          `yaml:"breaker"`           // 数据源连续失败时的熔断配置
+	SnapshotFallback SnapshotFallbackConfig `yaml:"snapshot_fallback"` // 所有数据源都失败时使用最近一次快照
+}
+
+// RetryConfig 重试策略配置：指数退避加随机抖动，响应带有 Retry-After 时按其等待
+type RetryConfig struct {
+	MaxAttempts     int     `yaml:"max_attempts"`     // 每次抓取最多请求次数（包括第一次），1 表示不重试
+	InitialInterval int     `yaml:"initial_interval"` // 第一次重试前的等待时间（秒）
+	MaxInterval     int     `yaml:"max_interval"`     // 单次等待时间上限（秒）
+	Multiplier      float64 `yaml:"multiplier"`       // 每次重试后等待时间的倍数
+	MaxElapsed      int     `yaml:"max_elapsed"`      // 每次抓取的总时长上限（秒），0 表示不限制
+}
+
+// BreakerConfig 熔断配置，熔断期间直接使用备用数据源或快照
+type BreakerConfig struct {
+	FailureThreshold int `yaml:"failure_threshold"` // 连续失败多少次后熔断，0 表示不熔断
+	Cooldown         int `yaml:"cooldown"`          // 熔断时长（秒）
+}
+
+// SnapshotFallbackConfig 数据源不可用时使用快照存储中最近一次成功抓取的结果
+type SnapshotFallbackConfig struct {
+	Enabled bool `yaml:"enabled"` // 需要同时启用 store
+	MaxAge  int  `yaml:"max_age"` // 快照最长可以是多少小时前的，0 表示不限制
+}
+
+// GitHubConfig GitHub Search API 数据源配置
+type GitHubConfig struct {
+	BaseURL  string `yaml:"base_url"`  // GitHub API 地址
+	Token    string `yaml:"token"`     // 访问令牌，可选，匿名请求限流较严格
+	MinStars int    `yaml:"min_stars"` // 最低 star 数
+	WebURL   string `yaml:"web_url"`   // github.com 地址，用于 github_trending 数据源
 }
 
 // EmailConfig 邮件配置
 type EmailConfig struct {
-	SMTPHost string   `yaml:"smtp_host"`
-	SMTPPort int      `yaml:"smtp_port"`
-	Username string   `yaml:"username"`
-	Password string   `yaml:"password"`
-	From     string   `yaml:"from"`
-	To       []string `yaml:"to"`
-	Subject  string   `yaml:"subject"`
-	UseHTML  bool     `yaml:"use_html"`
+	Enabled       bool     `yaml:"enabled"` // 是否发送邮件，默认 true；只使用其他通知渠道时可关闭
+	SMTPHost      string   `yaml:"smtp_host"`
+	SMTPPort      int      `yaml:"smtp_port"`
+	TLSMode       string   `yaml:"tls_mode"`        // "none", "starttls", "starttls-required" 或 "implicit"，为空时 465 端口使用 implicit，其他端口使用 starttls
+	CAFile        string   `yaml:"ca_file"`         // 自定义 CA 证书文件（PEM）
+	TLSServerName string   `yaml:"tls_server_name"` // 校验证书使用的服务器名称，为空时使用 smtp_host
+	Auth          string   `yaml:"auth"`            // 认证方式，"plain"（默认）, "login" 或 "cram-md5"
+	Timeout       int      `yaml:"timeout"`         // 连接和发送一封邮件的超时时间（秒），默认30
+	Username      string   `yaml:"username"`
+	Password      string   `yaml:"password"`
+	From          string   `yaml:"from"` // 发件人，可带显示名称，如 "Trending Bot <bot@example.com>"
+	To            []string `yaml:"to"`
+	Cc            []string `yaml:"cc"`       // 抄送
+	Bcc           []string `yaml:"bcc"`      // 密送
+	ReplyTo       []string `yaml:"reply_to"` // 回复地址
+	Subject       string   `yaml:"subject"`
+	UseHTML       bool     `yaml:"use_html"`
+	TemplatePath  string   `yaml:"template_path"`    // 自定义报告模板文件，为空时使用内置模板
+	Unsubscribe   []string `yaml:"list_unsubscribe"` // 退订地址（mailto: 或 https:），写入 List-Unsubscribe 邮件头
 }
 
 // QueryConfig 查询参数配置
 type QueryConfig struct {
-	Language string `yaml:"language"` // 编程语言，如 "go", "java", "all"
-	Period   string `yaml:"period"`   // 时间范围，如 "daily", "weekly", "monthly"
-	Limit    int    `yaml:"limit"`    // 获取数量，默认100
+	Language   Languages `yaml:"language"`    // 编程语言，如 "go", "all"，或列表 [go, rust, zig] 生成多语言汇总报告
+	Period     string    `yaml:"period"`      // 时间范围，如 "daily", "weekly", "monthly"
+	Limit      int       `yaml:"limit"`       // 每种语言的获取数量，默认100
+	OverallTop int       `yaml:"overall_top"` // 多语言报告中跨语言合并的总榜数量，0 表示不展示
 }
 
-// Load 从配置文件加载配置
+// Languages 编程语言列表
+// YAML 中既可以写单个语言 language: go，也可以写列表 language: [go, rust]，单个字符串中的逗号也会被拆分
+type Languages []string
+
+// ParseLanguages 解析逗号分隔的语言列表，如 "go,rust,zig"
+func ParseLanguages(s string) Languages {
+	var languages Languages
+	for _, language := range strings.Split(s, ",") {
+		if language = strings.TrimSpace(language); language != "" {
+			languages = append(languages, language)
+		}
+	}
+	return languages
+}
+
+// UnmarshalYAML 同时支持字符串和列表两种写法
+func (l *Languages) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		var s string
+		if err := node.Decode(&s); err != nil {
+			return err
+		}
+		*l = ParseLanguages(s)
+		return nil
+	}
+
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	languages := make(Languages, 0, len(list))
+	for _, language := range list {
+		languages = append(languages, strings.TrimSpace(language))
+	}
+	*l = languages
+	return nil
+}
+
+// String 以 ", " 连接的语言列表，用于日志和邮件主题
+func (l Languages) String() string {
+	return strings.Join(l, ", ")
+}
+
+// ReportConfig 报告配置，未设置的字段继承 query 和 email 中的值
+type ReportConfig struct {
+	Name         string         `yaml:"name"`          // 报告名称，用于日志和发送记录
+	Query        QueryConfig    `yaml:"query"`         // 查询参数
+	To           []string       `yaml:"to"`            // 收件人
+	Cc           []string       `yaml:"cc"`            // 抄送，未设置时使用 email.cc
+	Bcc          []string       `yaml:"bcc"`           // 密送，未设置时使用 email.bcc
+	Subject      string         `yaml:"subject"`       // 邮件主题模板，可使用 {{.Name}} {{.Language}} {{.Period}} {{.Date}} {{.Count}}
+	Format       string         `yaml:"format"`        // 报告格式，"html"、"text"、"markdown"、"json" 或 "csv"
+	Compact      bool           `yaml:"compact"`       // markdown 报告使用紧凑模式，每个仓库一行
+	TemplatePath string         `yaml:"template_path"` // 自定义模板文件，仅 html 和 text 格式使用，未设置时使用 email.template_path
+	Filters      []FilterConfig `yaml:"filters"`       // 该报告额外的过滤规则，在全局 filters 之后执行
+	Ranking      RankingConfig  `yaml:"ranking"`       // 该报告的排名策略，未设置时使用全局 ranking
+	Schedule     string         `yaml:"schedule"`      // 守护进程模式下的 cron 表达式，如 "30 7 * * *"，未设置时使用 schedule.cron
+	Timezone     string         `yaml:"timezone"`      // cron 表达式使用的时区，未设置时使用 schedule.timezone
+}
+
+// validate 验证重试策略
+func (r RetryConfig) validate() error {
+	if r.MaxAttempts < 1 {
+		return fmt.Errorf("retry max_attempts must be at least 1")
+	}
+	if r.InitialInterval < 0 || r.MaxInterval < 0 || r.MaxElapsed < 0 {
+		return fmt.Errorf("retry intervals must not be negative")
+	}
+	if r.MaxInterval > 0 && r.InitialInterval > r.MaxInterval {
+		return fmt.Errorf("retry initial_interval must not be greater than max_interval")
+	}
+	if r.Multiplier < 1 {
+		return fmt.Errorf("retry multiplier must be at least 1")
+	}
+	return nil
+}
+
+// ParseSchedule 解析报告的 cron 表达式和时区，未设置 schedule 时返回 nil
+func (r ReportConfig) ParseSchedule() (*schedule.Schedule, error) {
+	if r.Schedule == "" {
+		return nil, nil
+	}
+	location := time.Local
+	if r.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(r.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid timezone: %w", err)
+		}
+	}
+	return schedule.Parse(r.Schedule, location)
+}
+
+// RankingConfig 排名策略配置，过滤之后按策略重新排名
+type RankingConfig struct {
+	Strategy string             `yaml:"strategy"` // "source"（默认，保持数据源顺序）, "stars", "star_velocity", "forks", "pushes", "pull_requests", "total_score", "weighted"
+	Weights  map[string]float64 `yaml:"weights"`  // weighted 策略下各指标的权重，如 {stars: 1, forks: 0.5}
+}
+
+// FilterConfig 过滤规则配置
+// 设置的条件同时满足才算匹配；exclude 去掉匹配的仓库，include 只保留匹配的仓库
+type FilterConfig struct {
+	Name                string   `yaml:"name"`                 // 规则名称
+	Action              string   `yaml:"action"`               // "exclude"（默认）或 "include"
+	Reason              string   `yaml:"reason"`               // 过滤原因，显示在 dry-run 输出和日志中
+	Owners              []string `yaml:"owners"`               // 仓库所有者
+	NameRegex           string   `yaml:"name_regex"`           // 匹配 owner/name 的正则表达式
+	DescriptionKeywords []string `yaml:"description_keywords"` // 描述关键字，不区分大小写
+	Languages           []string `yaml:"languages"`            // 编程语言
+	Topics              []string `yaml:"topics"`               // GitHub topic，仅 github 数据源提供
+	MinStars            int      `yaml:"min_stars"`            // 最少 star 数
+	MinForks            int      `yaml:"min_forks"`            // 最少 fork 数
+	MinPushes           int      `yaml:"min_pushes"`           // 最少 push 数
+	MinPullRequests     int      `yaml:"min_pull_requests"`    // 最少 PR 数
+	Archived            bool     `yaml:"archived"`             // 匹配已归档的仓库，仅 github 数据源提供
+	EmptyDescription    bool     `yaml:"empty_description"`    // 匹配没有描述的仓库
+}
+
+// StoreConfig 快照存储配置
+type StoreConfig struct {
+	Enabled       bool   `yaml:"enabled"`        // 是否保存每次抓取的快照
+	Dir           string `yaml:"dir"`            // 快照目录
+	RetentionDays int    `yaml:"retention_days"` // 快照保留天数，0 表示永久保留
+}
+
+// LedgerConfig 发送账本配置
+// 账本按报告、时间窗口（daily 为日期，weekly 为 ISO 周，monthly 为月份）和收件人记录已发送的报告
+type LedgerConfig struct {
+	Enabled  bool   `yaml:"enabled"`   // 是否跳过同一时间窗口内已经发送过的报告
+	Path     string `yaml:"path"`      // 账本文件
+	LockName string `yaml:"lock_name"` // 发送期间持有的进程互斥锁名称，同时只有一次运行在发送
+}
+
+// FeedConfig Atom/RSS feed 配置
+// 每次运行时与上一次生成的 feed 合并，保留最近的 max_items 个条目
+type FeedConfig struct {
+	Enabled  bool   `yaml:"enabled"`   // 是否生成 feed
+	Path     string `yaml:"path"`      // 输出路径，可使用 {name} 为每个报告生成单独的 feed，否则所有报告写入同一个 feed
+	Format   string `yaml:"format"`    // "atom"（默认）或 "rss"
+	MaxItems int    `yaml:"max_items"` // 保留的条目数量，默认100
+	Title    string `yaml:"title"`     // feed 标题
+	Link     string `yaml:"link"`      // feed 对应的网页地址，可选
+}
+
+// ScheduleConfig 守护进程模式（-daemon）的定时配置
+type ScheduleConfig struct {
+	Cron          string `yaml:"cron"`            // 默认的 cron 表达式（分 时 日 月 周），报告未设置 schedule 时使用
+	Timezone      string `yaml:"timezone"`        // 时区，如 "Asia/Shanghai"，为空时使用本地时区
+	CatchUp       string `yaml:"catch_up"`        // 停机期间错过的运行："once"（默认，启动后补发一次）或 "skip"
+	CatchUpWindow int    `yaml:"catch_up_window"` // 只补发最近多少小时内错过的运行，0 表示不限制，默认24
+	StateFile     string `yaml:"state_file"`      // 记录每个报告上次运行时间的文件
+	LockName      string `yaml:"lock_name"`       // 进程互斥锁名称，同名的守护进程只能运行一个
+}
+
+// NotifierConfig 邮件之外的通知渠道配置
+type NotifierConfig struct {
+	Type     string            `yaml:"type"`      // "webhook", "slack", "teams", "dingtalk", "feishu" 或 "wecom"
+	Name     string            `yaml:"name"`      // 名称，用于日志，默认为类型
+	URL      string            `yaml:"url"`       // webhook 地址
+	Secret   string            `yaml:"secret"`    // 钉钉加签密钥或飞书签名校验密钥，可选
+	Headers  map[string]string `yaml:"headers"`   // 额外的请求头，仅 webhook 使用，如 Authorization
+	MaxItems int               `yaml:"max_items"` // 聊天消息中每个榜单最多展示的仓库数量，默认10
+	Timeout  int               `yaml:"timeout"`   // 超时时间（秒），默认30
+	Reports  []string          `yaml:"reports"`   // 只发送这些报告，为空表示发送所有报告
+}
+
+// notifierEnvs 通过环境变量配置的通知渠道，值为 webhook 地址
+var notifierEnvs = []struct {
+	kind      string
+	urlEnv    string
+	secretEnv string
+}{
+	{"webhook", "WEBHOOK_URL", ""},
+	{"slack", "SLACK_WEBHOOK_URL", ""},
+	{"teams", "TEAMS_WEBHOOK_URL", ""},
+	{"dingtalk", "DINGTALK_WEBHOOK_URL", "DINGTALK_SECRET"},
+	{"feishu", "FEISHU_WEBHOOK_URL", "FEISHU_SECRET"},
+	{"wecom", "WECOM_WEBHOOK_URL", ""},
+}
+
+// Load 从配置文件加载配置并验证
 func Load(configPath string) (*Config, error) {
+	config, err := LoadUnvalidated(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// 验证配置
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return config, nil
+}
+
+// LoadUnvalidated 从配置文件和环境变量加载配置但不验证
+// 供只读取部分配置的子命令使用（如 ledger 只需要账本路径），不要求 SMTP 服务器、账号和收件人
+func LoadUnvalidated(configPath string) (*Config, error) {
 	config := &Config{
 		API: APIConfig{
-			BaseURL: "https://api.ossinsight.io",
-			Timeout: 30,
+			Source:     "ossinsight",
+			BaseURL:    "https://api.ossinsight.io",
+			Timeout:    30,
+			DecodeMode: "lenient",
+			GitHub: GitHubConfig{
+				BaseURL:  "https://api.github.com",
+				MinStars: 50,
+				WebURL:   "https://github.com",
+			},
+			Retry: RetryConfig{
+				MaxAttempts:     4,
+				InitialInterval: 1,
+				MaxInterval:     30,
+				Multiplier:      2,
+				MaxElapsed:      120,
+			},
+			Breaker: BreakerConfig{
+				FailureThreshold: 3,
+				Cooldown:         300,
+			},
+			SnapshotFallback: SnapshotFallbackConfig{
+				Enabled: true,
+				MaxAge:  48,
+			},
 		},
 		Query: QueryConfig{
-			Language: "all",
+			Language: Languages{"all"},
 			Period:   "daily",
 			Limit:    100,
 		},
 		Email: EmailConfig{
+			Enabled:  true,
 			SMTPPort: 587,
 			Subject:  "GitHub Trending Repositories Report",
 			UseHTML:  true,
 		},
+		Store: StoreConfig{
+			Enabled: true,
+			Dir:     "data/snapshots",
+		},
+		Ranking: RankingConfig{
+			Strategy: "source",
+		},
+		Feed: FeedConfig{
+			Path:     "data/feed.xml",
+			Format:   "atom",
+			MaxItems: 100,
+			Title:    "GitHub Trending Repositories",
+		},
+		Ledger: LedgerConfig{
+			Enabled:  true,
+			Path:     "data/ledger.jsonl",
+			LockName: "trending-notifier",
+		},
+		Schedule: ScheduleConfig{
+			CatchUp:       "once",
+			CatchUpWindow: 24,
+			StateFile:     "data/schedule.json",
+			LockName:      "trending-notifier-daemon",
+		},
 	}
 
 	// 如果提供了配置文件路径，则从文件加载
@@ -72,11 +378,6 @@ func Load(configPath string) (*Config, error) {
 	// 从环境变量覆盖配置
 	loadFromEnv(config)
 
-	// 验证配置
-	if err := config.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid configuration: %w", err)
-	}
-
 	return config, nil
 }
 
@@ -98,6 +399,15 @@ func loadFromEnv(config *Config) {
 		log.Println("警告: 未找到 .env 文件，将使用系统环境变量")
 	}
 	// API配置
+	if v := os.Getenv("API_SOURCE"); v != "" {
+		config.API.Source = v
+	}
+	if v := os.Getenv("API_SOURCES"); v != "" {
+		config.API.Sources = strings.Split(v, ",")
+	}
+	if v := os.Getenv("API_FALLBACK_SOURCE"); v != "" {
+		config.API.FallbackSource = v
+	}
 	if v := os.Getenv("API_BASE_URL"); v != "" {
 		config.API.BaseURL = v
 	}
@@ -106,8 +416,42 @@ func loadFromEnv(config *Config) {
 			config.API.Timeout = timeout
 		}
 	}
+	if v := os.Getenv("API_DECODE_MODE"); v != "" {
+		config.API.DecodeMode = v
+	}
+	if v := os.Getenv("API_RETRY_MAX_ATTEMPTS"); v != "" {
+		if attempts, err := strconv.Atoi(v); err == nil {
+			config.API.Retry.MaxAttempts = attempts
+		}
+	}
+	if v := os.Getenv("API_RETRY_MAX_ELAPSED"); v != "" {
+		if elapsed, err := strconv.Atoi(v); err == nil {
+			config.API.Retry.MaxElapsed = elapsed
+		}
+	}
+	if v := os.Getenv("API_SNAPSHOT_FALLBACK"); v != "" {
+		config.API.SnapshotFallback.Enabled = v == "true" || v == "1"
+	}
+
+	if v := os.Getenv("GITHUB_API_BASE_URL"); v != "" {
+		config.API.GitHub.BaseURL = v
+	}
+	if v := os.Getenv("GITHUB_WEB_URL"); v != "" {
+		config.API.GitHub.WebURL = v
+	}
+	if v := os.Getenv("GITHUB_TOKEN"); v != "" {
+		config.API.GitHub.Token = v
+	}
+	if v := os.Getenv("GITHUB_MIN_STARS"); v != "" {
+		if minStars, err := strconv.Atoi(v); err == nil {
+			config.API.GitHub.MinStars = minStars
+		}
+	}
 
 	// 邮件配置
+	if v := os.Getenv("EMAIL_ENABLED"); v != "" {
+		config.Email.Enabled = v == "true" || v == "1"
+	}
 	if v := os.Getenv("SMTP_HOST"); v != "" {
 		log.Printf("本次读取的SMTP_HOST: %v", v)
 		config.Email.SMTPHost = v
@@ -117,6 +461,23 @@ func loadFromEnv(config *Config) {
 			config.Email.SMTPPort = port
 		}
 	}
+	if v := os.Getenv("SMTP_TLS_MODE"); v != "" {
+		config.Email.TLSMode = v
+	}
+	if v := os.Getenv("SMTP_CA_FILE"); v != "" {
+		config.Email.CAFile = v
+	}
+	if v := os.Getenv("SMTP_TLS_SERVER_NAME"); v != "" {
+		config.Email.TLSServerName = v
+	}
+	if v := os.Getenv("SMTP_AUTH"); v != "" {
+		config.Email.Auth = v
+	}
+	if v := os.Getenv("SMTP_TIMEOUT"); v != "" {
+		if timeout, err := strconv.Atoi(v); err == nil {
+			config.Email.Timeout = timeout
+		}
+	}
 	if v := os.Getenv("SMTP_USERNAME"); v != "" {
 		config.Email.Username = v
 	}
@@ -129,16 +490,31 @@ func loadFromEnv(config *Config) {
 	if v := os.Getenv("EMAIL_TO"); v != "" {
 		config.Email.To = strings.Split(v, ",")
 	}
+	if v := os.Getenv("EMAIL_CC"); v != "" {
+		config.Email.Cc = strings.Split(v, ",")
+	}
+	if v := os.Getenv("EMAIL_BCC"); v != "" {
+		config.Email.Bcc = strings.Split(v, ",")
+	}
+	if v := os.Getenv("EMAIL_REPLY_TO"); v != "" {
+		config.Email.ReplyTo = strings.Split(v, ",")
+	}
+	if v := os.Getenv("EMAIL_LIST_UNSUBSCRIBE"); v != "" {
+		config.Email.Unsubscribe = strings.Split(v, ",")
+	}
 	if v := os.Getenv("EMAIL_SUBJECT"); v != "" {
 		config.Email.Subject = v
 	}
 	if v := os.Getenv("EMAIL_USE_HTML"); v != "" {
 		config.Email.UseHTML = v == "true" || v == "1"
 	}
+	if v := os.Getenv("EMAIL_TEMPLATE_PATH"); v != "" {
+		config.Email.TemplatePath = v
+	}
 
 	// 查询配置
 	if v := os.Getenv("QUERY_LANGUAGE"); v != "" {
-		config.Query.Language = v
+		config.Query.Language = ParseLanguages(v)
 	}
 	if v := os.Getenv("QUERY_PERIOD"); v != "" {
 		config.Query.Period = v
@@ -148,40 +524,503 @@ func loadFromEnv(config *Config) {
 			config.Query.Limit = limit
 		}
 	}
+	if v := os.Getenv("QUERY_OVERALL_TOP"); v != "" {
+		if top, err := strconv.Atoi(v); err == nil {
+			config.Query.OverallTop = top
+		}
+	}
+
+	// 排名策略配置
+	if v := os.Getenv("RANKING_STRATEGY"); v != "" {
+		config.Ranking.Strategy = v
+	}
+
+	// 快照存储配置
+	if v := os.Getenv("STORE_ENABLED"); v != "" {
+		config.Store.Enabled = v == "true" || v == "1"
+	}
+	if v := os.Getenv("STORE_DIR"); v != "" {
+		config.Store.Dir = v
+	}
+	if v := os.Getenv("STORE_RETENTION_DAYS"); v != "" {
+		if days, err := strconv.Atoi(v); err == nil {
+			config.Store.RetentionDays = days
+		}
+	}
+
+	// feed 配置
+	if v := os.Getenv("FEED_ENABLED"); v != "" {
+		config.Feed.Enabled = v == "true" || v == "1"
+	}
+	if v := os.Getenv("FEED_PATH"); v != "" {
+		config.Feed.Path = v
+	}
+	if v := os.Getenv("FEED_FORMAT"); v != "" {
+		config.Feed.Format = v
+	}
+	if v := os.Getenv("FEED_MAX_ITEMS"); v != "" {
+		if maxItems, err := strconv.Atoi(v); err == nil {
+			config.Feed.MaxItems = maxItems
+		}
+	}
+
+	// 发送账本配置
+	if v := os.Getenv("LEDGER_ENABLED"); v != "" {
+		config.Ledger.Enabled = v == "true" || v == "1"
+	}
+	if v := os.Getenv("LEDGER_PATH"); v != "" {
+		config.Ledger.Path = v
+	}
+
+	// 定时配置
+	if v := os.Getenv("SCHEDULE_CRON"); v != "" {
+		config.Schedule.Cron = v
+	}
+	if v := os.Getenv("SCHEDULE_TIMEZONE"); v != "" {
+		config.Schedule.Timezone = v
+	}
+	if v := os.Getenv("SCHEDULE_CATCH_UP"); v != "" {
+		config.Schedule.CatchUp = v
+	}
+	if v := os.Getenv("SCHEDULE_STATE_FILE"); v != "" {
+		config.Schedule.StateFile = v
+	}
+
+	// 通知渠道配置，追加在配置文件中的渠道之后
+	for _, env := range notifierEnvs {
+		if v := os.Getenv(env.urlEnv); v != "" {
+			notifier := NotifierConfig{Type: env.kind, URL: v}
+			if env.secretEnv != "" {
+				notifier.Secret = os.Getenv(env.secretEnv)
+			}
+			config.Notifiers = append(config.Notifiers, notifier)
+		}
+	}
 }
 
 // Validate 验证配置
 func (c *Config) Validate() error {
 	// 验证邮件配置
-	if c.Email.SMTPHost == "" {
+	if c.Email.Enabled {
+		if err := c.Email.validate(); err != nil {
+			return err
+		}
+	} else if len(c.Notifiers) == 0 {
+		return fmt.Errorf("no notification channel enabled: enable email or configure notifiers")
+	}
+
+	// 验证API配置
+	validSources := map[string]bool{
+		"ossinsight":      true,
+		"github":          true,
+		"github_trending": true,
+	}
+	if !validSources[strings.ToLower(c.API.Source)] {
+		return fmt.Errorf("invalid API source: %s (must be ossinsight, github or github_trending)", c.API.Source)
+	}
+	seenSources := make(map[string]bool, len(c.API.Sources))
+	for _, source := range c.API.Sources {
+		name := strings.ToLower(strings.TrimSpace(source))
+		if !validSources[name] {
+			return fmt.Errorf("invalid API source in sources: %s (must be ossinsight, github or github_trending)", source)
+		}
+		if seenSources[name] {
+			return fmt.Errorf("duplicate API source in sources: %s", source)
+		}
+		seenSources[name] = true
+	}
+	if c.API.FallbackSource != "" && !validSources[strings.ToLower(c.API.FallbackSource)] {
+		return fmt.Errorf("invalid API fallback source: %s (must be ossinsight, github or github_trending)", c.API.FallbackSource)
+	}
+	if c.API.DecodeMode != "strict" && c.API.DecodeMode != "lenient" {
+		return fmt.Errorf("invalid API decode_mode: %s (must be strict or lenient)", c.API.DecodeMode)
+	}
+	if c.API.GitHub.MinStars < 0 {
+		return fmt.Errorf("github min_stars must not be negative")
+	}
+	if err := c.API.Retry.validate(); err != nil {
+		return err
+	}
+	if c.API.Breaker.FailureThreshold < 0 || c.API.Breaker.Cooldown < 0 {
+		return fmt.Errorf("breaker failure_threshold and cooldown must not be negative")
+	}
+	if c.API.Breaker.FailureThreshold > 0 && c.API.Breaker.Cooldown == 0 {
+		return fmt.Errorf("breaker cooldown is required when failure_threshold is set")
+	}
+	if c.API.SnapshotFallback.MaxAge < 0 {
+		return fmt.Errorf("snapshot_fallback max_age must not be negative")
+	}
+
+	// 验证查询配置
+	if err := c.Query.validate(); err != nil {
+		return err
+	}
+
+	// 验证报告配置
+	validFormats := map[string]bool{
+		"html":     true,
+		"text":     true,
+		"markdown": true,
+		"json":     true,
+		"csv":      true,
+	}
+	names := make(map[string]bool)
+	for _, report := range c.ReportProfiles() {
+		if names[report.Name] {
+			return fmt.Errorf("duplicate report name: %s", report.Name)
+		}
+		names[report.Name] = true
+
+		if err := report.Query.validate(); err != nil {
+			return fmt.Errorf("report %s: %w", report.Name, err)
+		}
+		if c.Email.Enabled && len(report.To) == 0 {
+			if len(c.Reports) == 0 {
+				return fmt.Errorf("at least one recipient email is required")
+			}
+			return fmt.Errorf("report %s: at least one recipient email is required", report.Name)
+		}
+		for _, list := range []struct {
+			field string
+			addrs []string
+		}{{"to", report.To}, {"cc", report.Cc}, {"bcc", report.Bcc}} {
+			if err := validateAddresses(list.field, list.addrs); err != nil {
+				return fmt.Errorf("report %s: %w", report.Name, err)
+			}
+		}
+		if !validFormats[report.Format] {
+			return fmt.Errorf("report %s: invalid format: %s (must be html, text, markdown, json or csv)", report.Name, report.Format)
+		}
+		if report.TemplatePath != "" {
+			if report.Format != "html" && report.Format != "text" {
+				return fmt.Errorf("report %s: template_path is only supported for html and text formats", report.Name)
+			}
+			if _, err := os.Stat(report.TemplatePath); err != nil {
+				return fmt.Errorf("report %s: invalid template_path: %w", report.Name, err)
+			}
+		}
+		if _, err := template.New("subject").Parse(report.Subject); err != nil {
+			return fmt.Errorf("report %s: invalid subject template: %w", report.Name, err)
+		}
+		if err := report.Ranking.validate(); err != nil {
+			return fmt.Errorf("report %s: %w", report.Name, err)
+		}
+		for i, rule := range report.Filters {
+			if err := rule.validate(); err != nil {
+				return fmt.Errorf("report %s: filter %d: %w", report.Name, i+1, err)
+			}
+		}
+		if _, err := report.ParseSchedule(); err != nil {
+			return fmt.Errorf("report %s: %w", report.Name, err)
+		}
+	}
+
+	// 验证通知渠道配置
+	validNotifiers := map[string]bool{
+		"webhook":  true,
+		"slack":    true,
+		"teams":    true,
+		"dingtalk": true,
+		"feishu":   true,
+		"wecom":    true,
+	}
+	for i, notifier := range c.Notifiers {
+		if !validNotifiers[strings.ToLower(notifier.Type)] {
+			return fmt.Errorf("notifier %d: invalid type: %s (must be webhook, slack, teams, dingtalk, feishu or wecom)", i+1, notifier.Type)
+		}
+		if notifier.URL == "" {
+			return fmt.Errorf("notifier %d: url is required", i+1)
+		}
+		if u, err := url.Parse(notifier.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("notifier %d: invalid url (must be an http or https URL)", i+1)
+		}
+		if notifier.MaxItems < 0 || notifier.Timeout < 0 {
+			return fmt.Errorf("notifier %d: max_items and timeout must not be negative", i+1)
+		}
+		for _, name := range notifier.Reports {
+			if !names[name] {
+				return fmt.Errorf("notifier %d: unknown report: %s", i+1, name)
+			}
+		}
+	}
+	// 不发送邮件时，每个报告至少要有一个通知渠道
+	if !c.Email.Enabled {
+		for _, report := range c.ReportProfiles() {
+			if !c.hasNotifier(report.Name) {
+				return fmt.Errorf("report %s: no notification channel enabled", report.Name)
+			}
+		}
+	}
+
+	// 验证定时配置
+	switch strings.ToLower(c.Schedule.CatchUp) {
+	case "once", "skip":
+	default:
+		return fmt.Errorf("invalid schedule catch_up: %s (must be once or skip)", c.Schedule.CatchUp)
+	}
+	if c.Schedule.CatchUpWindow < 0 {
+		return fmt.Errorf("schedule catch_up_window must not be negative")
+	}
+	if c.Schedule.StateFile == "" {
+		return fmt.Errorf("schedule state_file is required")
+	}
+
+	// 验证发送账本配置
+	if c.Ledger.Enabled && c.Ledger.Path == "" {
+		return fmt.Errorf("ledger path is required when ledger is enabled")
+	}
+	if c.Ledger.LockName == "" {
+		return fmt.Errorf("ledger lock_name is required")
+	}
+
+	// 验证快照存储配置
+	if c.Store.Enabled && c.Store.Dir == "" {
+		return fmt.Errorf("store directory is required when store is enabled")
+	}
+	if c.Store.RetentionDays < 0 {
+		return fmt.Errorf("store retention_days must not be negative")
+	}
+
+	// 验证 feed 配置
+	if c.Feed.Enabled {
+		if c.Feed.Path == "" {
+			return fmt.Errorf("feed path is required when feed is enabled")
+		}
+		switch strings.ToLower(c.Feed.Format) {
+		case "atom", "rss":
+		default:
+			return fmt.Errorf("invalid feed format: %s (must be atom or rss)", c.Feed.Format)
+		}
+		if c.Feed.MaxItems <= 0 {
+			return fmt.Errorf("feed max_items must be positive")
+		}
+	}
+
+	return nil
+}
+
+// validate 验证邮件配置
+func (e EmailConfig) validate() error {
+	if e.SMTPHost == "" {
 		return fmt.Errorf("SMTP host is required")
 	}
-	if c.Email.Username == "" {
+	if e.Username == "" {
 		return fmt.Errorf("SMTP username is required")
 	}
-	if c.Email.Password == "" {
+	if e.Password == "" {
 		return fmt.Errorf("SMTP password is required")
 	}
-	if c.Email.From == "" {
+	if e.From == "" {
 		return fmt.Errorf("email from address is required")
 	}
-	if len(c.Email.To) == 0 {
-		return fmt.Errorf("at least one recipient email is required")
+	switch strings.ToLower(e.TLSMode) {
+	case "", "none", "starttls", "starttls-required", "implicit":
+	default:
+		return fmt.Errorf("invalid SMTP tls_mode: %s (must be none, starttls, starttls-required or implicit)", e.TLSMode)
+	}
+	switch strings.ToLower(e.Auth) {
+	case "", "plain", "login", "cram-md5":
+	default:
+		return fmt.Errorf("invalid SMTP auth: %s (must be plain, login or cram-md5)", e.Auth)
+	}
+	if e.Timeout < 0 {
+		return fmt.Errorf("SMTP timeout must not be negative")
 	}
+	if e.CAFile != "" {
+		if _, err := os.Stat(e.CAFile); err != nil {
+			return fmt.Errorf("invalid SMTP ca_file: %w", err)
+		}
+	}
+	if _, err := mail.ParseAddress(e.From); err != nil {
+		return fmt.Errorf("invalid email from address: %w", err)
+	}
+	if err := validateAddresses("reply_to", e.ReplyTo); err != nil {
+		return err
+	}
+	for _, uri := range e.Unsubscribe {
+		if !strings.HasPrefix(uri, "mailto:") && !strings.HasPrefix(uri, "https://") && !strings.HasPrefix(uri, "http://") {
+			return fmt.Errorf("invalid list_unsubscribe: %s (must be a mailto: or http(s): URI)", uri)
+		}
+	}
+	return nil
+}
 
-	// 验证查询配置
+// hasNotifier 是否有通知渠道发送该报告
+func (c *Config) hasNotifier(report string) bool {
+	for _, notifier := range c.Notifiers {
+		if len(notifier.Reports) == 0 {
+			return true
+		}
+		for _, name := range notifier.Reports {
+			if name == report {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validateAddresses 验证邮件地址，地址可以带显示名称，如 "张三 <zhangsan@example.com>"
+func validateAddresses(field string, addrs []string) error {
+	for _, addr := range addrs {
+		if _, err := mail.ParseAddress(addr); err != nil {
+			return fmt.Errorf("invalid %s address %q: %w", field, addr, err)
+		}
+	}
+	return nil
+}
+
+// validate 验证查询参数
+func (q QueryConfig) validate() error {
 	validPeriods := map[string]bool{
 		"daily":   true,
 		"weekly":  true,
 		"monthly": true,
 	}
-	if !validPeriods[c.Query.Period] {
-		return fmt.Errorf("invalid period: %s (must be daily, weekly, or monthly)", c.Query.Period)
+	if !validPeriods[q.Period] {
+		return fmt.Errorf("invalid period: %s (must be daily, weekly, or monthly)", q.Period)
 	}
 
-	if c.Query.Limit <= 0 || c.Query.Limit > 100 {
+	if q.Limit <= 0 || q.Limit > 100 {
 		return fmt.Errorf("limit must be between 1 and 100")
 	}
 
+	if len(q.Language) == 0 {
+		return fmt.Errorf("at least one language is required")
+	}
+	seen := make(map[string]bool, len(q.Language))
+	for _, language := range q.Language {
+		if language == "" {
+			return fmt.Errorf("language must not be empty")
+		}
+		key := strings.ToLower(language)
+		if seen[key] {
+			return fmt.Errorf("duplicate language: %s", language)
+		}
+		seen[key] = true
+	}
+
+	if q.OverallTop < 0 {
+		return fmt.Errorf("overall_top must not be negative")
+	}
+
+	return nil
+}
+
+// validate 验证过滤规则
+func (f FilterConfig) validate() error {
+	switch strings.ToLower(f.Action) {
+	case "", "include", "exclude":
+	default:
+		return fmt.Errorf("invalid action: %s (must be include or exclude)", f.Action)
+	}
+	if f.NameRegex != "" {
+		if _, err := regexp.Compile(f.NameRegex); err != nil {
+			return fmt.Errorf("invalid name_regex: %w", err)
+		}
+	}
+	if f.MinStars < 0 || f.MinForks < 0 || f.MinPushes < 0 || f.MinPullRequests < 0 {
+		return fmt.Errorf("minimum values must not be negative")
+	}
+	// 没有条件的规则匹配所有仓库，一条空的 exclude 规则会清空所有报告
+	if len(f.Owners) == 0 && f.NameRegex == "" && len(f.DescriptionKeywords) == 0 && len(f.Languages) == 0 &&
+		len(f.Topics) == 0 && f.MinStars == 0 && f.MinForks == 0 && f.MinPushes == 0 && f.MinPullRequests == 0 &&
+		!f.Archived && !f.EmptyDescription {
+		return fmt.Errorf("no conditions set (owners, name_regex, description_keywords, languages, topics, min_*, archived or empty_description)")
+	}
 	return nil
 }
+
+// validate 验证排名策略
+func (r RankingConfig) validate() error {
+	metrics := map[string]bool{
+		"stars":         true,
+		"star_velocity": true,
+		"forks":         true,
+		"pushes":        true,
+		"pull_requests": true,
+		"total_score":   true,
+	}
+
+	switch strategy := strings.ToLower(r.Strategy); {
+	case strategy == "source" || metrics[strategy]:
+		return nil
+	case strategy == "weighted":
+		if len(r.Weights) == 0 {
+			return fmt.Errorf("ranking strategy weighted requires weights")
+		}
+		for metric := range r.Weights {
+			if !metrics[metric] {
+				return fmt.Errorf("invalid ranking weight: %s (must be stars, star_velocity, forks, pushes, pull_requests or total_score)", metric)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid ranking strategy: %s (must be source, stars, star_velocity, forks, pushes, pull_requests, total_score or weighted)", r.Strategy)
+	}
+}
+
+// ReportProfiles 返回需要生成的所有报告，未设置的字段使用 query 和 email 中的值补全
+// 没有配置 reports 时返回一个名为 "default" 的报告
+func (c *Config) ReportProfiles() []ReportConfig {
+	reports := c.Reports
+	if len(reports) == 0 {
+		reports = []ReportConfig{{Name: "default"}}
+	}
+
+	defaultFormat := "text"
+	if c.Email.UseHTML {
+		defaultFormat = "html"
+	}
+
+	profiles := make([]ReportConfig, 0, len(reports))
+	for i, report := range reports {
+		if report.Name == "" {
+			report.Name = fmt.Sprintf("report-%d", i+1)
+		}
+		if len(report.Query.Language) == 0 {
+			report.Query.Language = c.Query.Language
+		}
+		if report.Query.Period == "" {
+			report.Query.Period = c.Query.Period
+		}
+		if report.Query.Limit == 0 {
+			report.Query.Limit = c.Query.Limit
+		}
+		if report.Query.OverallTop == 0 {
+			report.Query.OverallTop = c.Query.OverallTop
+		}
+		if len(report.To) == 0 {
+			report.To = c.Email.To
+		}
+		if len(report.Cc) == 0 {
+			report.Cc = c.Email.Cc
+		}
+		if len(report.Bcc) == 0 {
+			report.Bcc = c.Email.Bcc
+		}
+		if report.Subject == "" {
+			report.Subject = c.Email.Subject
+		}
+		if report.Ranking.Strategy == "" {
+			report.Ranking = c.Ranking
+		}
+		report.Filters = append(append([]FilterConfig(nil), c.Filters...), report.Filters...)
+		report.Format = strings.ToLower(report.Format)
+		if report.Format == "" {
+			report.Format = defaultFormat
+		}
+		if report.TemplatePath == "" && (report.Format == "html" || report.Format == "text") {
+			report.TemplatePath = c.Email.TemplatePath
+		}
+		if report.Schedule == "" {
+			report.Schedule = c.Schedule.Cron
+		}
+		if report.Timezone == "" {
+			report.Timezone = c.Schedule.Timezone
+		}
+		profiles = append(profiles, report)
+	}
+
+	return profiles
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFilterConfigValidate(t *testing.T) {
+	tests := []struct {
+		filter FilterConfig
+		want   string // 为空表示合法
+	}{
+		{FilterConfig{Owners: []string{"acme"}}, ""},
+		{FilterConfig{Action: "include", MinStars: 20}, ""},
+		{FilterConfig{EmptyDescription: true}, ""},
+		{FilterConfig{}, "no conditions set"},
+		{FilterConfig{Name: "empty", Action: "exclude", Reason: "oops"}, "no conditions set"},
+		{FilterConfig{Action: "drop", Owners: []string{"acme"}}, "invalid action"},
+		{FilterConfig{NameRegex: "("}, "invalid name_regex"},
+		{FilterConfig{MinStars: -1}, "must not be negative"},
+	}
+
+	for _, tt := range tests {
+		err := tt.filter.validate()
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("%+v: unexpected error %v", tt.filter, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%+v: err = %v, want it to contain %q", tt.filter, err, tt.want)
+		}
+	}
+}
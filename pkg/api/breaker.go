@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen 数据源连续失败后熔断，冷却期间的请求直接返回该错误
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// CircuitBreaker 数据源熔断器
+// 连续 threshold 次临时性失败（见 IsRetryable）后熔断 cooldown 时长，期间不再请求该数据源，
+// 冷却结束后只放行一次试探请求（并发的其他请求仍返回 ErrCircuitOpen）：成功或得到不可重试的错误则恢复，临时性失败则再次熔断。
+// 熔断器的状态与数据源分开保存，守护进程每次运行重新创建数据源时可以沿用同一个熔断器
+type CircuitBreaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu            sync.Mutex
+	failures      int       // 连续失败次数
+	openUntil     time.Time // 熔断结束时间，零值表示未熔断
+	halfOpen      bool      // 冷却结束后正在试探数据源
+	trialInFlight bool      // 试探请求正在进行，其他请求需要等它完成
+}
+
+// NewCircuitBreaker 创建熔断器，threshold 小于等于 0 时不熔断
+func NewCircuitBreaker(name string, threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		name:      name,
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// Wrap 返回经过熔断器请求 source 的数据源
+func (b *CircuitBreaker) Wrap(source TrendingSource) TrendingSource {
+	if b.threshold <= 0 {
+		return source
+	}
+	return &breakerSource{source: source, breaker: b}
+}
+
+// allow 是否允许请求，熔断期间返回剩余的冷却时间，试探请求进行中时返回 0
+// 允许的请求结束后必须调用 record 或 release
+func (b *CircuitBreaker) allow() (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.trialInFlight {
+		return 0, false
+	}
+	if !b.openUntil.IsZero() {
+		if remaining := b.openUntil.Sub(b.now()); remaining > 0 {
+			return remaining, false
+		}
+		// 冷却结束，放行一次请求试探数据源是否恢复
+		log.Printf("Circuit breaker for %s half-open, trying the source again", b.name)
+		b.openUntil = time.Time{}
+		b.halfOpen = true
+	}
+	if b.halfOpen {
+		b.trialInFlight = true
+	}
+	return 0, true
+}
+
+// release 请求被取消、没有结果时调用，让下一个请求重新试探
+func (b *CircuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trialInFlight = false
+}
+
+// record 记录一次请求的结果
+func (b *CircuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trialInFlight = false
+	if err == nil {
+		if b.halfOpen {
+			log.Printf("Circuit breaker for %s closed, source recovered", b.name)
+		}
+		b.failures = 0
+		b.halfOpen = false
+		return
+	}
+	// 参数错误、解析失败等不代表数据源不可用；试探请求得到这类结果说明数据源已经响应，视为恢复
+	if !IsRetryable(err) {
+		if b.halfOpen {
+			log.Printf("Circuit breaker for %s closed, source responded: %v", b.name, err)
+			b.failures = 0
+			b.halfOpen = false
+		}
+		return
+	}
+
+	b.failures++
+	if b.halfOpen || b.failures >= b.threshold {
+		b.halfOpen = false
+		b.openUntil = b.now().Add(b.cooldown)
+		log.Printf("Circuit breaker for %s opened after %d consecutive failures, skipping it for %s",
+			b.name, b.failures, b.cooldown)
+	}
+}
+
+// breakerSource 经过熔断器请求的数据源
+type breakerSource struct {
+	source  TrendingSource
+	breaker *CircuitBreaker
+}
+
+// Name 数据源名称
+func (s *breakerSource) Name() string {
+	return s.source.Name()
+}
+
+// FetchTrending 熔断期间或试探请求进行中时直接返回 ErrCircuitOpen，否则请求数据源并记录结果
+func (s *breakerSource) FetchTrending(ctx context.Context, language string, period string, limit int) ([]Repository, error) {
+	if remaining, ok := s.breaker.allow(); !ok {
+		if remaining == 0 {
+			return nil, fmt.Errorf("source %s: %w (trial request in progress)", s.Name(), ErrCircuitOpen)
+		}
+		return nil, fmt.Errorf("source %s: %w (retrying in %s)", s.Name(), ErrCircuitOpen, remaining.Round(time.Second))
+	}
+
+	repos, err := s.source.FetchTrending(ctx, language, period, limit)
+	if ctx.Err() == nil {
+		s.breaker.record(err)
+	} else {
+		s.breaker.release()
+	}
+	return repos, err
+}
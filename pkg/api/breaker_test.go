@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingSource 在 release 关闭前阻塞，用于模拟进行中的请求
+type blockingSource struct {
+	started chan struct{}
+	release chan struct{}
+	err     error
+}
+
+func (s *blockingSource) Name() string {
+	return "blocking"
+}
+
+func (s *blockingSource) FetchTrending(ctx context.Context, language string, period string, limit int) ([]Repository, error) {
+	s.started <- struct{}{}
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []Repository{{FullName: "a/one"}}, nil
+}
+
+// openBreaker 返回已经熔断且冷却结束的熔断器
+func openBreaker(t *testing.T) *CircuitBreaker {
+	t.Helper()
+	now := time.Now()
+	breaker := NewCircuitBreaker("test", 1, time.Minute)
+	breaker.now = func() time.Time { return now }
+
+	failing := breaker.Wrap(&fakeSource{name: "test", err: &ServerError{StatusCode: 502}})
+	if _, err := failing.FetchTrending(context.Background(), "go", "daily", 10); errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("first request rejected: %v", err)
+	}
+	if _, err := failing.FetchTrending(context.Background(), "go", "daily", 10); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen after threshold", err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	return breaker
+}
+
+func TestCircuitBreakerHalfOpenAllowsOneTrial(t *testing.T) {
+	for _, trialErr := range []error{nil, &ServerError{StatusCode: 503}} {
+		breaker := openBreaker(t)
+		slow := &blockingSource{started: make(chan struct{}, 1), release: make(chan struct{}), err: trialErr}
+		source := breaker.Wrap(slow)
+
+		var trialResult error
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, trialResult = source.FetchTrending(context.Background(), "go", "daily", 10)
+		}()
+		<-slow.started
+
+		// 试探请求进行中，其他请求直接被拒绝
+		for i := 0; i < 3; i++ {
+			if _, err := source.FetchTrending(context.Background(), "go", "daily", 10); !errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("concurrent request during trial: err = %v, want ErrCircuitOpen", err)
+			}
+		}
+
+		close(slow.release)
+		wg.Wait()
+		if !errors.Is(trialResult, trialErr) {
+			t.Fatalf("trial err = %v, want %v", trialResult, trialErr)
+		}
+
+		next := breaker.Wrap(&fakeSource{name: "test", repos: []Repository{{FullName: "a/one"}}})
+		_, err := next.FetchTrending(context.Background(), "go", "daily", 10)
+		if trialErr == nil && err != nil {
+			t.Errorf("after successful trial: err = %v, want breaker closed", err)
+		}
+		if trialErr != nil && !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("after failed trial: err = %v, want ErrCircuitOpen", err)
+		}
+	}
+}
+
+func TestCircuitBreakerCanceledTrialReleases(t *testing.T) {
+	breaker := openBreaker(t)
+	slow := &blockingSource{started: make(chan struct{}, 1), release: make(chan struct{})}
+	source := breaker.Wrap(slow)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := source.FetchTrending(ctx, "go", "daily", 10)
+		done <- err
+	}()
+	<-slow.started
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+
+	// 被取消的试探请求不影响下一次试探
+	next := breaker.Wrap(&fakeSource{name: "test", repos: []Repository{{FullName: "a/one"}}})
+	if _, err := next.FetchTrending(context.Background(), "go", "daily", 10); err != nil {
+		t.Errorf("err = %v, want a new trial to be allowed", err)
+	}
+}
+
+func TestCircuitBreakerNonRetryableTrialCloses(t *testing.T) {
+	now := time.Now()
+	breaker := NewCircuitBreaker("test", 2, time.Minute)
+	breaker.now = func() time.Time { return now }
+	failing := breaker.Wrap(&fakeSource{name: "test", err: &ServerError{StatusCode: 502}})
+	for i := 0; i < 2; i++ {
+		failing.FetchTrending(context.Background(), "go", "daily", 10)
+	}
+	if _, err := failing.FetchTrending(context.Background(), "go", "daily", 10); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen after threshold", err)
+	}
+	now = now.Add(2 * time.Minute)
+
+	// 试探请求得到不可重试的错误，数据源已经响应，熔断器恢复
+	badRequest := breaker.Wrap(&fakeSource{name: "test", err: &NotFoundError{StatusCode: 404}})
+	if _, err := badRequest.FetchTrending(context.Background(), "go", "daily", 10); errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("trial rejected: %v", err)
+	}
+
+	// 连续失败次数已清零，一次临时性失败不会再次熔断
+	failing.FetchTrending(context.Background(), "go", "daily", 10)
+	next := breaker.Wrap(&fakeSource{name: "test", repos: []Repository{{FullName: "a/one"}}})
+	if _, err := next.FetchTrending(context.Background(), "go", "daily", 10); err != nil {
+		t.Errorf("err = %v, want the breaker to stay closed below the threshold", err)
+	}
+}
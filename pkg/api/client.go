@@ -12,33 +12,80 @@ import (
 	"time"
 )
 
+// 请求使用的 User-Agent
+const userAgent = "OSS-Insight-Trending-Notifier/1.0"
+
 // Client GitHub API 客户端
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
+	source     TrendingSource
 }
 
 // Repository 仓库信息
 type Repository struct {
-	RepoID          int64  `json:"repo_id"`
-	RepoName        string `json:"repo_name"`
-	FullName        string `json:"full_name"` // GitHub API uses full_name
-	Description     string `json:"description"`
-	Language        string `json:"language"`
-	Stars           int    `json:"stars"`
-	StargazersCount int    `json:"stargazers_count"` // GitHub API field
-	Forks           int    `json:"forks"`
-	ForksCount      int    `json:"forks_count"` // GitHub API field
-	Stargazers      int    `json:"stargazers"`
-	StarsDelta      int    `json:"stars_delta"`
-	ForksDelta      int    `json:"forks_delta"`
-	StargazersDelta int    `json:"stargazers_delta"`
-	Pushes          int    `json:"pushes"`         // 最近时间段内的 push 数量
-	PullRequests    int    `json:"pull_requests"`  // 最近时间段内的 PR 数量
-	Rank            int    `json:"rank"`
-	URL             string `json:"url"`
-	HTMLURL         string `json:"html_url"` // GitHub API uses html_url
-	Owner           string `json:"owner"`
+	RepoID          int64         `json:"repo_id"`
+	RepoName        string        `json:"repo_name"`
+	FullName        string        `json:"full_name"` // GitHub API uses full_name
+	Description     string        `json:"description"`
+	Language        string        `json:"language"`
+	Stars           int           `json:"stars"`
+	StargazersCount int           `json:"stargazers_count"` // GitHub API field
+	Forks           int           `json:"forks"`
+	ForksCount      int           `json:"forks_count"` // GitHub API field
+	Stargazers      int           `json:"stargazers"`
+	StarsDelta      int           `json:"stars_delta"`
+	ForksDelta      int           `json:"forks_delta"`
+	StargazersDelta int           `json:"stargazers_delta"`
+	Pushes          int           `json:"pushes"`                 // 最近时间段内的 push 数量
+	PullRequests    int           `json:"pull_requests"`          // 最近时间段内的 PR 数量
+	TotalScore      float64       `json:"total_score"`            // OSSInsight 综合得分
+	Contributors    []string      `json:"contributors,omitempty"` // OSSInsight 统计的主要贡献者
+	Collections     []string      `json:"collections,omitempty"`  // OSSInsight 收录该仓库的 collection
+	Rank            int           `json:"rank"`
+	PreviousRank    int           `json:"previous_rank"` // 上一次快照中的排名，0 表示上次未上榜
+	RankDelta       int           `json:"rank_delta"`    // 排名变化，正数表示上升
+	URL             string        `json:"url"`
+	HTMLURL         string        `json:"html_url"` // GitHub API uses html_url
+	Owner           string        `json:"owner"`
+	Topics          []string      `json:"topics,omitempty"`   // GitHub topics，仅 GitHub Search API 提供
+	Archived        bool          `json:"archived,omitempty"` // 是否已归档，仅 GitHub Search API 提供
+	BuiltBy         []Contributor `json:"built_by,omitempty"` // github.com/trending 页面上的 "Built by" 贡献者
+	Sources         []SourceRank  `json:"sources,omitempty"`  // 多数据源合并时收录该仓库的数据源及排名
+}
+
+// Contributor 仓库贡献者
+type Contributor struct {
+	Login     string `json:"login"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+// Key 仓库的唯一标识，优先使用 RepoID，其次使用小写的仓库全名
+func (r *Repository) Key() string {
+	if r.RepoID != 0 {
+		return strconv.FormatInt(r.RepoID, 10)
+	}
+	name := r.FullName
+	if name == "" {
+		name = r.RepoName
+	}
+	return strings.ToLower(name)
+}
+
+// TotalStars star 总数，兼容不同数据源的 star 字段
+func (r *Repository) TotalStars() int {
+	if r.Stars == 0 && r.StargazersCount > 0 {
+		return r.StargazersCount
+	}
+	return r.Stars
+}
+
+// TotalForks fork 总数，兼容不同数据源的 fork 字段
+func (r *Repository) TotalForks() int {
+	if r.Forks == 0 && r.ForksCount > 0 {
+		return r.ForksCount
+	}
+	return r.Forks
 }
 
 // TrendingResponse API响应 (OSSInsight format)
@@ -83,294 +130,180 @@ type GitHubSearchResponse struct {
 
 // GitHubRepo GitHub仓库信息
 type GitHubRepo struct {
-	ID              int64  `json:"id"`
-	Name            string `json:"name"`
-	FullName        string `json:"full_name"`
-	Description     string `json:"description"`
-	Language        string `json:"language"`
-	StargazersCount int    `json:"stargazers_count"`
-	ForksCount      int    `json:"forks_count"`
-	HTMLURL         string `json:"html_url"`
+	ID              int64    `json:"id"`
+	Name            string   `json:"name"`
+	FullName        string   `json:"full_name"`
+	Description     string   `json:"description"`
+	Language        string   `json:"language"`
+	StargazersCount int      `json:"stargazers_count"`
+	ForksCount      int      `json:"forks_count"`
+	HTMLURL         string   `json:"html_url"`
+	Topics          []string `json:"topics"`
+	Archived        bool     `json:"archived"`
 	Owner           struct {
 		Login string `json:"login"`
 	} `json:"owner"`
 }
 
-// NewClient 创建新的API客户端
+// toRepository 转换 GitHub 格式到统一格式
+func (item *GitHubRepo) toRepository(rank int) Repository {
+	return Repository{
+		RepoID:          item.ID,
+		RepoName:        item.FullName,
+		FullName:        item.FullName,
+		Description:     item.Description,
+		Language:        item.Language,
+		Stars:           item.StargazersCount,
+		StargazersCount: item.StargazersCount,
+		Forks:           item.ForksCount,
+		ForksCount:      item.ForksCount,
+		Rank:            rank,
+		URL:             item.HTMLURL,
+		HTMLURL:         item.HTMLURL,
+		Owner:           item.Owner.Login,
+		Topics:          item.Topics,
+		Archived:        item.Archived,
+	}
+}
+
+// NewClient 创建新的API客户端，默认使用 OSSInsight 数据源
 func NewClient(baseURL string, timeout time.Duration) *Client {
+	httpClient := &http.Client{
+		Timeout: timeout,
+	}
 	return &Client{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: timeout,
-		},
+		baseURL:    baseURL,
+		httpClient: httpClient,
+		source:     NewOSSInsightSource(baseURL, httpClient),
 	}
 }
 
+// NewClientWithSource 使用指定数据源创建API客户端
+func NewClientWithSource(baseURL string, timeout time.Duration, source TrendingSource) *Client {
+	client := NewClient(baseURL, timeout)
+	client.source = source
+	return client
+}
+
 // GetTrendingRepos 获取trending repositories
 // language: 编程语言，如 "go", "java", "all"
 // period: 时间范围，如 "daily", "weekly", "monthly"
 // limit: 获取数量
 func (c *Client) GetTrendingRepos(ctx context.Context, language string, period string, limit int) ([]Repository, error) {
-	// 构建API URL
-	apiURL, err := c.buildTrendingURL(language, period, limit)
-	if err != nil {
-		return nil, fmt.Errorf("failed to build URL: %w", err)
-	}
-
-	// 创建HTTP请求
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
+	return c.source.FetchTrending(ctx, language, period, limit)
+}
 
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", "OSS-Insight-Trending-Notifier/1.0")
+// Source 返回客户端使用的数据源
+func (c *Client) Source() TrendingSource {
+	return c.source
+}
 
-	// 发送请求
-	resp, err := c.httpClient.Do(req)
+// parseTrendingResponse 识别并解析 trending 响应（OSSInsight SQL、GitHub Search 或旧版 OSSInsight 格式），最多返回 limit 个
+// lenient 模式下跳过的行记录到 ctx 携带的 ParseWarnings 中
+func parseTrendingResponse(ctx context.Context, source string, body []byte, limit int, mode DecodeMode) ([]Repository, error) {
+	result, err := decodeTrending(body, mode)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
+	result.warn(ctx, source)
 
-	// 检查响应状态
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	repos := result.repos
+	if limit > 0 && len(repos) > limit {
+		repos = repos[:limit]
 	}
+	return repos, nil
+}
 
-	// 读取响应体
-	body, err := io.ReadAll(resp.Body)
+// GetCollectionRepos 获取特定collection的repositories
+// 这是一个备用方法，如果trending API不可用
+func (c *Client) GetCollectionRepos(ctx context.Context, collection string, limit int) ([]Repository, error) {
+	endpoint := fmt.Sprintf("%s/collections/%s/repos", c.baseURL, collection)
+
+	u, err := url.Parse(endpoint)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, err
 	}
 
-	// 优先尝试解析 OSSInsight SQL endpoint 响应
-	var ossResult OSSInsightSQLResponse
-	if err := json.Unmarshal(body, &ossResult); err == nil && ossResult.Type == "sql_endpoint" && len(ossResult.Data.Rows) > 0 {
-		// 转换 OSSInsight SQL 格式到统一格式
-		repos := make([]Repository, 0, len(ossResult.Data.Rows))
-		for i, row := range ossResult.Data.Rows {
-			// 解析字符串到整数
-			repoID, _ := strconv.ParseInt(row.RepoID, 10, 64)
-			stars, _ := strconv.Atoi(row.Stars)
-			forks, _ := strconv.Atoi(row.Forks)
-			pushes, _ := strconv.Atoi(row.Pushes)
-			pullRequests, _ := strconv.Atoi(row.PullRequests)
-
-			// 提取 owner (repo_name 格式为 "owner/repo")
-			owner := ""
-			if idx := strings.Index(row.RepoName, "/"); idx > 0 {
-				owner = row.RepoName[:idx]
-			}
-
-			repo := Repository{
-				RepoID:          repoID,
-				RepoName:        row.RepoName,
-				FullName:        row.RepoName,
-				Description:     row.Description,
-				Language:        row.PrimaryLanguage,
-				Stars:           stars,
-				StargazersCount: stars,
-				Forks:           forks,
-				ForksCount:      forks,
-				Pushes:          pushes,
-				PullRequests:    pullRequests,
-				Rank:            i + 1,
-				URL:             fmt.Sprintf("https://github.com/%s", row.RepoName),
-				HTMLURL:         fmt.Sprintf("https://github.com/%s", row.RepoName),
-				Owner:           owner,
-			}
-			repos = append(repos, repo)
-
-			// 如果达到 limit，停止添加
-			if limit > 0 && len(repos) >= limit {
-				break
-			}
-		}
-		return repos, nil
-	}
+	q := u.Query()
+	q.Set("limit", fmt.Sprintf("%d", limit))
+	u.RawQuery = q.Encode()
 
-	// 尝试解析 GitHub Search API 响应（备用）
-	var ghResult GitHubSearchResponse
-	if err := json.Unmarshal(body, &ghResult); err == nil && len(ghResult.Items) > 0 {
-		// 转换 GitHub 格式到统一格式
-		repos := make([]Repository, 0, len(ghResult.Items))
-		for i, item := range ghResult.Items {
-			repo := Repository{
-				RepoID:          item.ID,
-				RepoName:        item.FullName,
-				FullName:        item.FullName,
-				Description:     item.Description,
-				Language:        item.Language,
-				Stars:           item.StargazersCount,
-				StargazersCount: item.StargazersCount,
-				Forks:           item.ForksCount,
-				ForksCount:      item.ForksCount,
-				Rank:            i + 1,
-				URL:             item.HTMLURL,
-				HTMLURL:         item.HTMLURL,
-				Owner:           item.Owner.Login,
-			}
-			repos = append(repos, repo)
-		}
-		return repos, nil
+	body, err := fetchBody(ctx, c.httpClient, u.String(), nil)
+	if err != nil {
+		return nil, err
 	}
 
-	// 尝试解析旧版 OSSInsight 格式（备用）
 	var result TrendingResponse
 	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	// 设置排名和URL
-	for i := range result.Data {
-		result.Data[i].Rank = i + 1
-		if result.Data[i].URL == "" && result.Data[i].HTMLURL != "" {
-			result.Data[i].URL = result.Data[i].HTMLURL
-		}
-		if result.Data[i].URL == "" {
-			result.Data[i].URL = fmt.Sprintf("https://github.com/%s", result.Data[i].RepoName)
-		}
-		// 标准化 Stars 和 Forks 字段
-		if result.Data[i].Stars == 0 && result.Data[i].StargazersCount > 0 {
-			result.Data[i].Stars = result.Data[i].StargazersCount
-		}
-		if result.Data[i].Forks == 0 && result.Data[i].ForksCount > 0 {
-			result.Data[i].Forks = result.Data[i].ForksCount
-		}
-		// 提取owner
-		if result.Data[i].Owner == "" && result.Data[i].RepoName != "" {
-			if idx := len(result.Data[i].RepoName); idx > 0 {
-				for j := 0; j < len(result.Data[i].RepoName); j++ {
-					if result.Data[i].RepoName[j] == '/' {
-						result.Data[i].Owner = result.Data[i].RepoName[:j]
-						break
-					}
-				}
-			}
-		}
+		return nil, newDecodeError("json", err, body)
 	}
 
 	return result.Data, nil
 }
 
-// buildTrendingURL 构建trending API URL
-func (c *Client) buildTrendingURL(language string, period string, limit int) (string, error) {
-	// 使用 OSSInsight Trending API 获取真正的 trending 仓库
-	// 该 API 返回指定时间段内 star 增长最快的项目
-	endpoint := "https://api.ossinsight.io/v1/trends/repos/"
-
-	u, err := url.Parse(endpoint)
-	if err != nil {
-		return "", err
-	}
-
-	// 映射 period 参数到 OSSInsight API 格式
-	var ossinsightPeriod string
-	switch period {
-	case "daily", "past_day", "past_24_hours":
-		ossinsightPeriod = "past_24_hours"
-	case "weekly", "past_7_days", "past_week":
-		ossinsightPeriod = "past_week"
-	case "monthly", "past_month", "past_28_days":
-		ossinsightPeriod = "past_month"
-	case "past_3_months":
-		ossinsightPeriod = "past_3_months"
-	default:
-		ossinsightPeriod = "past_week" // 默认一周
-	}
-
-	// 映射 language 参数 - OSSInsight 使用首字母大写
-	var ossinsightLanguage string
-	if language == "" || language == "all" {
-		ossinsightLanguage = "All"
-	} else {
-		// 将首字母大写（如 "go" -> "Go", "javascript" -> "JavaScript"）
-		ossinsightLanguage = strings.ToUpper(language[:1]) + strings.ToLower(language[1:])
-		// 特殊处理常见语言名称
-		switch strings.ToLower(language) {
-		case "javascript":
-			ossinsightLanguage = "JavaScript"
-		case "typescript":
-			ossinsightLanguage = "TypeScript"
-		case "c++":
-			ossinsightLanguage = "C++"
-		case "c#":
-			ossinsightLanguage = "C#"
-		case "php":
-			ossinsightLanguage = "PHP"
-		case "html":
-			ossinsightLanguage = "HTML"
-		case "css":
-			ossinsightLanguage = "CSS"
-		case "plpgsql":
-			ossinsightLanguage = "PLpgSQL"
-		case "tsql":
-			ossinsightLanguage = "TSQL"
-		case "hcl":
-			ossinsightLanguage = "HCL"
-		case "cmake":
-			ossinsightLanguage = "CMake"
-		case "powershell":
-			ossinsightLanguage = "PowerShell"
-		case "matlab":
-			ossinsightLanguage = "MATLAB"
-		case "objective-c":
-			ossinsightLanguage = "Objective-C"
+// splitList 解析逗号分隔的列表，忽略空项
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
 		}
 	}
-
-	q := u.Query()
-	q.Set("period", ossinsightPeriod)
-	q.Set("language", ossinsightLanguage)
-	u.RawQuery = q.Encode()
-
-	return u.String(), nil
+	return items
 }
 
-// GetCollectionRepos 获取特定collection的repositories
-// 这是一个备用方法，如果trending API不可用
-func (c *Client) GetCollectionRepos(ctx context.Context, collection string, limit int) ([]Repository, error) {
-	endpoint := fmt.Sprintf("%s/collections/%s/repos", c.baseURL, collection)
+// httpResponse 已读取完毕的 HTTP 响应
+type httpResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
 
-	u, err := url.Parse(endpoint)
+// fetchBody 发送 GET 请求并返回响应体，非 200 响应按状态码返回 RateLimitError、NotFoundError、ServerError 或 ClientError
+func fetchBody(ctx context.Context, httpClient *http.Client, rawURL string, header http.Header) ([]byte, error) {
+	resp, err := fetch(ctx, httpClient, rawURL, header)
 	if err != nil {
 		return nil, err
 	}
 
-	q := u.Query()
-	q.Set("limit", fmt.Sprintf("%d", limit))
-	u.RawQuery = q.Encode()
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError(resp, time.Now())
+	}
+
+	return resp.Body, nil
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+// fetch 发送 GET 请求并读取完整响应，不检查状态码
+// header 中的值会覆盖默认的 Accept 和 User-Agent
+func fetch(ctx context.Context, httpClient *http.Client, rawURL string, header http.Header) (*httpResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", "OSS-Insight-Trending-Notifier/1.0")
+	req.Header.Set("User-Agent", userAgent)
+	for key, values := range header {
+		req.Header.Del(key)
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
-	}
-
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	var result TrendingResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	return result.Data, nil
+	return &httpResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	}, nil
 }
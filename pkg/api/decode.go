@@ -0,0 +1,413 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DecodeMode 响应中出现无法解析的数据行时的处理方式
+type DecodeMode string
+
+const (
+	// DecodeStrict 任何一行无法解析都返回 DecodeError
+	DecodeStrict DecodeMode = "strict"
+	// DecodeLenient 跳过无法解析的行并计数，通过 ParseWarnings 报告，所有行都无法解析时返回 DecodeError
+	DecodeLenient DecodeMode = "lenient"
+)
+
+// trending 接口的响应格式
+const (
+	FormatOSSInsightSQL    = "ossinsight_sql"    // OSSInsight SQL endpoint：{"type": "sql_endpoint", "data": {"rows": [...]}}
+	FormatGitHubSearch     = "github_search"     // GitHub Search API：{"items": [...]}
+	FormatOSSInsightLegacy = "ossinsight_legacy" // 旧版 OSSInsight：{"data": [...]}
+)
+
+var (
+	// ErrUnknownFormat 响应不是任何已知的格式
+	ErrUnknownFormat = errors.New("unknown response format")
+	// ErrMissingField 必填字段为空或缺失
+	ErrMissingField = errors.New("missing required field")
+)
+
+// FieldError 数据行中无法解析的字段
+type FieldError struct {
+	Row   int    // 行号，从 1 开始
+	Field string // 字段名，如 "stars"
+	Value string // 原始值（截断）
+	Err   error
+}
+
+// Error 如 `row 3: field stars: strconv.Atoi: parsing "1.2k": invalid syntax`
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("row %d: field %s: %v", e.Row, e.Field, e.Err)
+}
+
+// Unwrap 返回解析错误
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// decodeResult 按某种格式解析响应的结果
+type decodeResult struct {
+	format  string
+	repos   []Repository  // 成功解析的行，按响应中的顺序
+	total   int           // 响应中的总行数
+	skipped int           // 无法解析而跳过的行数
+	errs    []*FieldError // 所有无法解析的字段，一行可能有多个
+}
+
+// decodeTrending 识别响应格式并解析，strict 模式下任何一行无法解析都返回 DecodeError，
+// lenient 模式下跳过这些行，所有行都无法解析时才返回 DecodeError
+func decodeTrending(body []byte, mode DecodeMode) (*decodeResult, error) {
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(body, &top); err != nil {
+		return nil, newDecodeError("json", err, body)
+	}
+
+	var result *decodeResult
+	var err error
+	switch format := detectFormat(top); format {
+	case FormatOSSInsightSQL:
+		result, err = decodeOSSInsightSQL(top["data"])
+	case FormatGitHubSearch:
+		result, err = decodeRows(format, top["items"], decodeGitHubSearchRow)
+	case FormatOSSInsightLegacy:
+		result, err = decodeRows(format, top["data"], decodeLegacyRow)
+	default:
+		err = fmt.Errorf("%w (top-level keys: %s)", ErrUnknownFormat, strings.Join(sortedKeys(top), ", "))
+	}
+	if err != nil {
+		return nil, newDecodeError("json", err, body)
+	}
+	if err := result.check(mode, body); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// check strict 模式下有任何一行无法解析、或所有行都无法解析时返回 DecodeError
+func (r *decodeResult) check(mode DecodeMode, body []byte) error {
+	if r.skipped > 0 && (mode == DecodeStrict || r.skipped == r.total) {
+		err := fmt.Errorf("%s response: %d of %d rows malformed, first: %w",
+			r.format, r.skipped, r.total, r.errs[0])
+		return newDecodeError("json", err, body)
+	}
+	return nil
+}
+
+// warn 有跳过的行时记录 ParseWarning
+func (r *decodeResult) warn(ctx context.Context, source string) {
+	if r.skipped == 0 {
+		return
+	}
+	reportParseWarning(ctx, ParseWarning{
+		Source:  source,
+		Format:  r.format,
+		Total:   r.total,
+		Skipped: r.skipped,
+		Errors:  r.errs,
+	})
+}
+
+// detectFormat 按顶层字段识别响应格式，无法识别时返回空字符串
+func detectFormat(top map[string]json.RawMessage) string {
+	if raw, ok := top["type"]; ok {
+		var typ string
+		if json.Unmarshal(raw, &typ) == nil && typ == "sql_endpoint" {
+			return FormatOSSInsightSQL
+		}
+		return ""
+	}
+	if _, ok := top["items"]; ok {
+		return FormatGitHubSearch
+	}
+	if raw, ok := top["data"]; ok && isJSONArray(raw) {
+		return FormatOSSInsightLegacy
+	}
+	return ""
+}
+
+// decodeOSSInsightSQL 解析 SQL endpoint 响应中的 data.rows
+func decodeOSSInsightSQL(data json.RawMessage) (*decodeResult, error) {
+	var payload struct {
+		Rows json.RawMessage `json:"rows"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("%s response: data: %w", FormatOSSInsightSQL, err)
+	}
+	return decodeRows(FormatOSSInsightSQL, payload.Rows, decodeOSSInsightRow)
+}
+
+// decodeRows 逐行解析 JSON 数组，一行中的字段错误不影响其他行
+func decodeRows(format string, raw json.RawMessage, decodeRow func(row int, raw json.RawMessage) (Repository, []*FieldError)) (*decodeResult, error) {
+	var rows []json.RawMessage
+	if len(raw) > 0 && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &rows); err != nil {
+			return nil, fmt.Errorf("%s response: rows: %w", format, err)
+		}
+	}
+
+	result := &decodeResult{format: format, total: len(rows)}
+	for i, raw := range rows {
+		repo, errs := decodeRow(i+1, raw)
+		if len(errs) > 0 {
+			result.skipped++
+			result.errs = append(result.errs, errs...)
+			continue
+		}
+		repo.Rank = len(result.repos) + 1
+		result.repos = append(result.repos, repo)
+	}
+	return result, nil
+}
+
+// decodeOSSInsightRow 解析 SQL endpoint 的一行，数值字段均为字符串
+// repo_name 和 stars 必填，其余数值字段为空时视为 0
+func decodeOSSInsightRow(row int, raw json.RawMessage) (Repository, []*FieldError) {
+	var r OSSInsightRow
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return Repository{}, []*FieldError{rowError(row, err)}
+	}
+
+	p := fieldParser{row: row}
+	repoID := p.int64("repo_id", r.RepoID)
+	repoName := p.name("repo_name", r.RepoName)
+	stars := p.int("stars", r.Stars, true)
+	forks := p.int("forks", r.Forks, false)
+	pushes := p.int("pushes", r.Pushes, false)
+	pullRequests := p.int("pull_requests", r.PullRequests, false)
+	totalScore := p.float("total_score", r.TotalScore)
+	if len(p.errs) > 0 {
+		return Repository{}, p.errs
+	}
+
+	return Repository{
+		RepoID:          repoID,
+		RepoName:        repoName,
+		FullName:        repoName,
+		Description:     r.Description,
+		Language:        r.PrimaryLanguage,
+		Stars:           stars,
+		StargazersCount: stars,
+		Forks:           forks,
+		ForksCount:      forks,
+		Pushes:          pushes,
+		PullRequests:    pullRequests,
+		TotalScore:      totalScore,
+		Contributors:    splitList(r.ContributorLogins),
+		Collections:     splitList(r.CollectionNames),
+		URL:             "https://github.com/" + repoName,
+		HTMLURL:         "https://github.com/" + repoName,
+		Owner:           repoOwner(repoName),
+	}, nil
+}
+
+// decodeGitHubSearchRow 解析 GitHub Search API 的一项，full_name 必填
+func decodeGitHubSearchRow(row int, raw json.RawMessage) (Repository, []*FieldError) {
+	var item GitHubRepo
+	if err := json.Unmarshal(raw, &item); err != nil {
+		return Repository{}, []*FieldError{rowError(row, err)}
+	}
+
+	p := fieldParser{row: row}
+	p.name("full_name", item.FullName)
+	if len(p.errs) > 0 {
+		return Repository{}, p.errs
+	}
+	return item.toRepository(0), nil
+}
+
+// decodeLegacyRow 解析旧版 OSSInsight 格式的一行，repo_name 或 full_name 必填
+func decodeLegacyRow(row int, raw json.RawMessage) (Repository, []*FieldError) {
+	var repo Repository
+	if err := json.Unmarshal(raw, &repo); err != nil {
+		return Repository{}, []*FieldError{rowError(row, err)}
+	}
+
+	if repo.RepoName == "" {
+		repo.RepoName = repo.FullName
+	}
+	if repo.FullName == "" {
+		repo.FullName = repo.RepoName
+	}
+	p := fieldParser{row: row}
+	p.name("repo_name", repo.RepoName)
+	if len(p.errs) > 0 {
+		return Repository{}, p.errs
+	}
+
+	if repo.URL == "" {
+		repo.URL = repo.HTMLURL
+	}
+	if repo.URL == "" {
+		repo.URL = "https://github.com/" + repo.RepoName
+	}
+	// 标准化 Stars 和 Forks 字段
+	repo.Stars = repo.TotalStars()
+	repo.Forks = repo.TotalForks()
+	if repo.Owner == "" {
+		repo.Owner = repoOwner(repo.RepoName)
+	}
+	return repo, nil
+}
+
+// fieldParser 解析一行中的字段，记录所有无法解析的字段
+type fieldParser struct {
+	row  int
+	errs []*FieldError
+}
+
+func (p *fieldParser) fail(field, value string, err error) {
+	p.errs = append(p.errs, &FieldError{Row: p.row, Field: field, Value: truncateBody([]byte(value)), Err: err})
+}
+
+// name 仓库全名，必须为 "owner/repo" 格式
+func (p *fieldParser) name(field, value string) string {
+	value = strings.TrimSpace(value)
+	switch {
+	case value == "":
+		p.fail(field, value, ErrMissingField)
+	case repoOwner(value) == "":
+		p.fail(field, value, fmt.Errorf("invalid repository name %q", value))
+	}
+	return value
+}
+
+// int 解析整数，required 为 false 时空值视为 0
+func (p *fieldParser) int(field, value string, required bool) int {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		if required {
+			p.fail(field, value, ErrMissingField)
+		}
+		return 0
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		p.fail(field, value, err)
+	}
+	return n
+}
+
+// int64 解析可选的 64 位整数，空值视为 0
+func (p *fieldParser) int64(field, value string) int64 {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		p.fail(field, value, err)
+	}
+	return n
+}
+
+// float 解析可选的浮点数，空值视为 0
+func (p *fieldParser) float(field, value string) float64 {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		p.fail(field, value, err)
+	}
+	return f
+}
+
+// rowError 把整行的 JSON 解析错误转换为 FieldError，类型不匹配时定位到具体字段
+func rowError(row int, err error) *FieldError {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return &FieldError{Row: row, Field: typeErr.Field, Value: typeErr.Value, Err: err}
+	}
+	return &FieldError{Row: row, Field: "(row)", Err: err}
+}
+
+// repoOwner 从 "owner/repo" 中提取 owner，格式不正确时返回空字符串
+func repoOwner(name string) string {
+	if idx := strings.Index(name, "/"); idx > 0 && idx < len(name)-1 {
+		return name[:idx]
+	}
+	return ""
+}
+
+func isJSONArray(raw json.RawMessage) bool {
+	s := strings.TrimSpace(string(raw))
+	return strings.HasPrefix(s, "[")
+}
+
+func sortedKeys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ParseWarning lenient 模式下一次响应中跳过的数据行
+type ParseWarning struct {
+	Source  string
+	Format  string
+	Total   int           // 响应中的总行数
+	Skipped int           // 跳过的行数
+	Errors  []*FieldError // 无法解析的字段
+}
+
+// String 如 `ossinsight: skipped 2 of 100 malformed rows in ossinsight_sql response (row 3: field stars: ...; and 1 more)`
+func (w ParseWarning) String() string {
+	msg := fmt.Sprintf("%s: skipped %d of %d malformed rows in %s response", w.Source, w.Skipped, w.Total, w.Format)
+	if len(w.Errors) == 0 {
+		return msg
+	}
+	detail := w.Errors[0].Error()
+	if more := len(w.Errors) - 1; more > 0 {
+		detail += fmt.Sprintf("; and %d more", more)
+	}
+	return msg + " (" + detail + ")"
+}
+
+// ParseWarnings 收集一次抓取中各数据源的 ParseWarning，可以并发使用
+type ParseWarnings struct {
+	mu       sync.Mutex
+	warnings []ParseWarning
+}
+
+// List 返回收集到的警告
+func (w *ParseWarnings) List() []ParseWarning {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]ParseWarning(nil), w.warnings...)
+}
+
+func (w *ParseWarnings) add(warning ParseWarning) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.warnings = append(w.warnings, warning)
+}
+
+type parseWarningsKey struct{}
+
+// WithParseWarnings 返回携带 warnings 的 context，使用该 context 抓取时数据源把跳过的行记录到 warnings 中
+func WithParseWarnings(ctx context.Context, warnings *ParseWarnings) context.Context {
+	return context.WithValue(ctx, parseWarningsKey{}, warnings)
+}
+
+// reportParseWarning 记录日志，并在 context 携带 ParseWarnings 时加入其中
+func reportParseWarning(ctx context.Context, warning ParseWarning) {
+	log.Printf("Warning: %s: skipped %d of %d malformed rows in %s response", warning.Source, warning.Skipped, warning.Total, warning.Format)
+	for _, err := range warning.Errors {
+		log.Printf("Warning: %s: %v", warning.Source, err)
+	}
+	if warnings, ok := ctx.Value(parseWarningsKey{}).(*ParseWarnings); ok {
+		warnings.add(warning)
+	}
+}
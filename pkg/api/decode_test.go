@@ -0,0 +1,205 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const (
+	sqlBody = `{"type":"sql_endpoint","data":{"rows":[
+		{"repo_id":"1","repo_name":"a/one","stars":"10","forks":"2","total_score":"1.5"},
+		{"repo_id":"2","repo_name":"b/two","stars":"1.2k"},
+		{"repo_id":"3","repo_name":"c/three","stars":"7"}]}}`
+	searchBody = `{"total_count":3,"items":[
+		{"id":1,"full_name":"a/one","stargazers_count":10},
+		{"id":2,"full_name":"b/two","stargazers_count":"1.2k"},
+		{"id":3,"full_name":"c/three","owner":{"login":"c"},"stargazers_count":7}]}`
+	legacyBody = `{"data":[
+		{"repo_name":"a/one","stars":10},
+		{"stars":5},
+		{"full_name":"c/three","stargazers_count":7}]}`
+)
+
+func TestDecodeTrendingDetectsFormat(t *testing.T) {
+	tests := []struct {
+		body   string
+		format string
+	}{
+		{sqlBody, FormatOSSInsightSQL},
+		{searchBody, FormatGitHubSearch},
+		{legacyBody, FormatOSSInsightLegacy},
+		{`{"type":"sql_endpoint","data":{"rows":[]}}`, FormatOSSInsightSQL},
+		{`{"items":[]}`, FormatGitHubSearch},
+	}
+
+	for _, tt := range tests {
+		result, err := decodeTrending([]byte(tt.body), DecodeLenient)
+		if err != nil {
+			t.Errorf("%s: %v", tt.format, err)
+			continue
+		}
+		if result.format != tt.format {
+			t.Errorf("format = %s, want %s", result.format, tt.format)
+		}
+	}
+}
+
+func TestDecodeTrendingUnknownFormat(t *testing.T) {
+	for _, body := range []string{
+		`{"result":{"repos":[]}}`,
+		`{"type":"graphql","data":{"rows":[]}}`,
+		`{"data":{"rows":[]}}`,
+		`[1, 2, 3]`,
+		`{not json`,
+	} {
+		_, err := decodeTrending([]byte(body), DecodeLenient)
+		var decodeErr *DecodeError
+		if !errors.As(err, &decodeErr) {
+			t.Errorf("%s: err = %v, want *DecodeError", body, err)
+		}
+	}
+
+	_, err := decodeTrending([]byte(`{"result":{}}`), DecodeLenient)
+	if !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("err = %v, want ErrUnknownFormat", err)
+	}
+}
+
+func TestDecodeTrendingStrict(t *testing.T) {
+	for _, body := range []string{sqlBody, searchBody, legacyBody} {
+		_, err := decodeTrending([]byte(body), DecodeStrict)
+		var decodeErr *DecodeError
+		if !errors.As(err, &decodeErr) {
+			t.Fatalf("err = %v, want *DecodeError", err)
+		}
+		var fieldErr *FieldError
+		if !errors.As(err, &fieldErr) {
+			t.Fatalf("err = %v, want *FieldError", err)
+		}
+		if fieldErr.Row != 2 {
+			t.Errorf("Row = %d, want 2 (%v)", fieldErr.Row, fieldErr)
+		}
+	}
+}
+
+func TestDecodeTrendingLenient(t *testing.T) {
+	tests := []struct {
+		body  string
+		field string
+	}{
+		{sqlBody, "stars"},
+		{searchBody, "stargazers_count"},
+		{legacyBody, "repo_name"},
+	}
+
+	for _, tt := range tests {
+		result, err := decodeTrending([]byte(tt.body), DecodeLenient)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.total != 3 || result.skipped != 1 || len(result.repos) != 2 {
+			t.Errorf("%s: total/skipped/repos = %d/%d/%d, want 3/1/2", result.format, result.total, result.skipped, len(result.repos))
+		}
+		if len(result.errs) != 1 || result.errs[0].Row != 2 || result.errs[0].Field != tt.field {
+			t.Errorf("%s: errs = %v, want row 2 field %s", result.format, result.errs, tt.field)
+		}
+		for i, repo := range result.repos {
+			if repo.Rank != i+1 {
+				t.Errorf("%s: %s Rank = %d, want %d", result.format, repo.FullName, repo.Rank, i+1)
+			}
+		}
+		if repo := result.repos[1]; repo.FullName != "c/three" || repo.TotalStars() != 7 || repo.Owner != "c" {
+			t.Errorf("%s: second repo = %+v", result.format, repo)
+		}
+	}
+}
+
+func TestDecodeTrendingFieldErrors(t *testing.T) {
+	body := `{"type":"sql_endpoint","data":{"rows":[
+		{"repo_name":"a/one","stars":"10"},
+		{"repo_name":"b/two","stars":""},
+		{"repo_name":"noslash","stars":"1","forks":"x"}]}}`
+
+	result, err := decodeTrending([]byte(body), DecodeLenient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]string, 0, len(result.errs))
+	for _, fieldErr := range result.errs {
+		got = append(got, fmt.Sprintf("%d:%s", fieldErr.Row, fieldErr.Field))
+	}
+	if want := "2:stars 3:repo_name 3:forks"; strings.Join(got, " ") != want {
+		t.Errorf("field errors = %s, want %s", strings.Join(got, " "), want)
+	}
+	if !errors.Is(result.errs[0], ErrMissingField) {
+		t.Errorf("empty stars: err = %v, want ErrMissingField", result.errs[0])
+	}
+	if result.skipped != 2 {
+		t.Errorf("skipped = %d, want 2", result.skipped)
+	}
+}
+
+func TestDecodeTrendingAllRowsMalformed(t *testing.T) {
+	body := `{"type":"sql_endpoint","data":{"rows":[{"repo_name":"a/x","stars":"n/a"}]}}`
+	_, err := decodeTrending([]byte(body), DecodeLenient)
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("err = %v, want *DecodeError", err)
+	}
+}
+
+func TestParseTrendingResponseWarningsAndLimit(t *testing.T) {
+	var warnings ParseWarnings
+	ctx := WithParseWarnings(context.Background(), &warnings)
+
+	repos, err := parseTrendingResponse(ctx, SourceOSSInsight, []byte(legacyBody), 1, DecodeLenient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 1 || repos[0].FullName != "a/one" {
+		t.Errorf("repos = %+v, want only a/one", repos)
+	}
+
+	list := warnings.List()
+	if len(list) != 1 {
+		t.Fatalf("got %d warnings, want 1", len(list))
+	}
+	if w := list[0]; w.Source != SourceOSSInsight || w.Format != FormatOSSInsightLegacy || w.Skipped != 1 || w.Total != 3 {
+		t.Errorf("warning = %+v", w)
+	}
+	if s := list[0].String(); !strings.Contains(s, "skipped 1 of 3 malformed rows") || !strings.Contains(s, "row 2: field repo_name") {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+func TestGitHubSourceDecodeMode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, searchBody)
+	}))
+	defer server.Close()
+
+	source := NewGitHubSource(server.URL, "", 0, server.Client())
+	var warnings ParseWarnings
+	repos, err := source.FetchTrending(WithParseWarnings(context.Background(), &warnings), "go", "daily", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 2 || repos[1].FullName != "c/three" || repos[1].Rank != 2 {
+		t.Errorf("repos = %+v", repos)
+	}
+	if list := warnings.List(); len(list) != 1 || list[0].Source != SourceGitHub || list[0].Format != FormatGitHubSearch {
+		t.Errorf("warnings = %+v", list)
+	}
+
+	source.decodeMode = DecodeStrict
+	_, err = source.FetchTrending(context.Background(), "go", "daily", 10)
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Field != "stargazers_count" {
+		t.Errorf("strict: err = %v, want *FieldError for stargazers_count", err)
+	}
+}
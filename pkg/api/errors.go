@@ -0,0 +1,161 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 错误中保留的响应体最大长度（字节），HTML 错误页等过长的响应会被截断
+const maxErrorBody = 256
+
+// RateLimitError 请求被限流（429，或 GitHub 限流额度用尽时的 403）
+type RateLimitError struct {
+	StatusCode int
+	Reset      time.Time     // 限流解除的时间，未知时为零值
+	RetryAfter time.Duration // 响应头 Retry-After 要求的等待时间，未设置时为 0
+	Body       string        // 截断后的响应体
+}
+
+// Error 如 "rate limited (status 429), resets at 2024-01-02 15:04:05: ..."
+func (e *RateLimitError) Error() string {
+	msg := fmt.Sprintf("rate limited (status %d)", e.StatusCode)
+	switch {
+	case !e.Reset.IsZero():
+		msg += ", resets at " + e.Reset.Local().Format("2006-01-02 15:04:05")
+	case e.RetryAfter > 0:
+		msg += ", retry after " + e.RetryAfter.String()
+	}
+	return withBody(msg, e.Body)
+}
+
+// NotFoundError 请求的资源不存在（404），通常是数据源不支持该语言
+type NotFoundError struct {
+	StatusCode int
+	Body       string // 截断后的响应体
+}
+
+// Error 如 "not found (status 404): ..."
+func (e *NotFoundError) Error() string {
+	return withBody(fmt.Sprintf("not found (status %d)", e.StatusCode), e.Body)
+}
+
+// ServerError 数据源服务端错误（5xx），通常是临时性故障
+type ServerError struct {
+	StatusCode int
+	RetryAfter time.Duration // 响应头 Retry-After 要求的等待时间（常见于 503），未设置时为 0
+	Body       string        // 截断后的响应体
+}
+
+// Error 如 "server error (status 502): ..."
+func (e *ServerError) Error() string {
+	return withBody(fmt.Sprintf("server error (status %d)", e.StatusCode), e.Body)
+}
+
+// ClientError 其他 4xx 响应，如参数错误（400、422）或认证失败（401、403）
+type ClientError struct {
+	StatusCode int
+	Body       string // 截断后的响应体
+}
+
+// Error 如 "API returned status 400: ..."
+func (e *ClientError) Error() string {
+	return withBody(fmt.Sprintf("API returned status %d", e.StatusCode), e.Body)
+}
+
+// DecodeError 响应无法解析
+type DecodeError struct {
+	Format string // 期望的响应格式，如 "json"、"html"
+	Err    error  // 解析错误
+	Body   string // 截断后的响应体
+}
+
+// Error 如 "failed to decode json response: ..."
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("failed to decode %s response: %v", e.Format, e.Err)
+}
+
+// Unwrap 返回解析错误
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// newStatusError 按状态码把非 200 响应转换为对应的错误类型
+func newStatusError(resp *httpResponse, now time.Time) error {
+	body := truncateBody(resp.Body)
+	retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), now)
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return &RateLimitError{
+			StatusCode: resp.StatusCode,
+			Reset:      rateLimitReset(resp.Header, retryAfter, now),
+			RetryAfter: retryAfter,
+			Body:       body,
+		}
+	case resp.StatusCode == http.StatusNotFound:
+		return &NotFoundError{StatusCode: resp.StatusCode, Body: body}
+	case resp.StatusCode >= 500:
+		return &ServerError{StatusCode: resp.StatusCode, RetryAfter: retryAfter, Body: body}
+	default:
+		return &ClientError{StatusCode: resp.StatusCode, Body: body}
+	}
+}
+
+// newDecodeError 创建 DecodeError，保留截断后的响应体便于排查
+func newDecodeError(format string, err error, body []byte) *DecodeError {
+	return &DecodeError{Format: format, Err: err, Body: truncateBody(body)}
+}
+
+// rateLimitReset 限流解除时间：优先使用 X-RateLimit-Reset（Unix 时间戳），其次使用 Retry-After
+func rateLimitReset(header http.Header, retryAfter time.Duration, now time.Time) time.Time {
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil && reset > 0 {
+		return time.Unix(reset, 0)
+	}
+	if retryAfter > 0 {
+		return now.Add(retryAfter)
+	}
+	return time.Time{}
+}
+
+// parseRetryAfter 解析 Retry-After，支持秒数和 HTTP 日期两种格式，无效或已过期时返回 0
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(v); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// truncateBody 去掉首尾空白，超过 maxErrorBody 时在字符边界截断
+func truncateBody(body []byte) string {
+	s := strings.TrimSpace(string(body))
+	if len(s) <= maxErrorBody {
+		return s
+	}
+	cut := maxErrorBody
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "…"
+}
+
+// withBody 在错误信息后附加响应体
+func withBody(msg, body string) string {
+	if body == "" {
+		return msg
+	}
+	return msg + ": " + body
+}
@@ -0,0 +1,246 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GitHub API 默认地址
+const DefaultGitHubBaseURL = "https://api.github.com"
+
+// GitHub Search API 单页最大数量
+const githubMaxPerPage = 100
+
+// GitHub Search API 最多返回 1000 条结果
+const githubMaxResults = 1000
+
+// 等待 GitHub 限流重置的最长时间，超过则直接返回错误
+const githubMaxRateLimitWait = time.Minute
+
+// 等待 GitHub 限流重置的最短时间，避免重置时间已过或响应头异常时连续请求
+const githubMinRateLimitWait = time.Second
+
+// 一次请求中最多等待限流重置的次数，之后返回 RateLimitError，由 RetrySource 决定是否重试
+const githubMaxRateLimitWaits = 2
+
+// GitHubSource GitHub Search API 数据源
+// 按 "created:>DATE stars:>N language:X" 查询时间范围内创建且 star 最多的仓库
+type GitHubSource struct {
+	baseURL    string
+	token      string
+	minStars   int
+	httpClient *http.Client
+	decodeMode DecodeMode
+	now        func() time.Time
+}
+
+// NewGitHubSource 创建 GitHub Search API 数据源
+// baseURL 为空时使用 DefaultGitHubBaseURL，token 为空时以匿名身份请求
+func NewGitHubSource(baseURL, token string, minStars int, httpClient *http.Client) *GitHubSource {
+	if baseURL == "" {
+		baseURL = DefaultGitHubBaseURL
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &GitHubSource{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      token,
+		minStars:   minStars,
+		httpClient: httpClient,
+		decodeMode: DecodeLenient,
+		now:        time.Now,
+	}
+}
+
+// Name 数据源名称
+func (s *GitHubSource) Name() string {
+	return SourceGitHub
+}
+
+// FetchTrending 分页获取 GitHub Search 结果，直到达到 limit 或没有更多结果
+// 每一页按 decodeMode 逐行解析，lenient 模式下所有页跳过的行合并为一条 ParseWarning
+func (s *GitHubSource) FetchTrending(ctx context.Context, language string, period string, limit int) ([]Repository, error) {
+	if limit <= 0 || limit > githubMaxResults {
+		limit = githubMaxResults
+	}
+
+	perPage := limit
+	if perPage > githubMaxPerPage {
+		perPage = githubMaxPerPage
+	}
+
+	query := s.buildQuery(language, period)
+	repos := make([]Repository, 0, limit)
+	decoded := &decodeResult{format: FormatGitHubSearch}
+
+	for page := 1; len(repos) < limit; page++ {
+		result, totalCount, err := s.fetchPage(ctx, query, page, perPage)
+		if err != nil {
+			return nil, err
+		}
+
+		// 行号按所有页连续编号
+		for _, fieldErr := range result.errs {
+			fieldErr.Row += (page - 1) * perPage
+		}
+		decoded.total += result.total
+		decoded.skipped += result.skipped
+		decoded.errs = append(decoded.errs, result.errs...)
+
+		for _, repo := range result.repos {
+			repo.Rank = len(repos) + 1
+			repos = append(repos, repo)
+			if len(repos) >= limit {
+				break
+			}
+		}
+
+		// 没有更多结果
+		if result.total < perPage || page*perPage >= totalCount || page*perPage >= githubMaxResults {
+			break
+		}
+	}
+
+	decoded.warn(ctx, s.Name())
+	return repos, nil
+}
+
+// fetchPage 获取一页搜索结果
+// 遇到限流时最多等待 githubMaxRateLimitWaits 次（每次 1 秒到 1 分钟），仍被限流或需要等待更久时返回 RateLimitError
+func (s *GitHubSource) fetchPage(ctx context.Context, query string, page, perPage int) (*decodeResult, int, error) {
+	u, err := url.Parse(s.baseURL + "/search/repositories")
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to build URL: %w", err)
+	}
+
+	q := u.Query()
+	q.Set("q", query)
+	q.Set("sort", "stars")
+	q.Set("order", "desc")
+	q.Set("per_page", strconv.Itoa(perPage))
+	q.Set("page", strconv.Itoa(page))
+	u.RawQuery = q.Encode()
+
+	header := http.Header{}
+	header.Set("Accept", "application/vnd.github+json")
+	header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if s.token != "" {
+		header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	for waits := 0; ; waits++ {
+		resp, err := fetch(ctx, s.httpClient, u.String(), header)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if resp.StatusCode == http.StatusOK {
+			return s.decodePage(resp.Body)
+		}
+
+		wait, limited := s.rateLimitWait(resp)
+		if !limited {
+			return nil, 0, newStatusError(resp, s.now())
+		}
+		if wait < githubMinRateLimitWait {
+			wait = githubMinRateLimitWait
+		}
+		if wait > githubMaxRateLimitWait || waits >= githubMaxRateLimitWaits {
+			now := s.now()
+			return nil, 0, &RateLimitError{
+				StatusCode: resp.StatusCode,
+				Reset:      rateLimitReset(resp.Header, wait, now),
+				RetryAfter: wait,
+				Body:       truncateBody(resp.Body),
+			}
+		}
+		log.Printf("GitHub rate limit hit (status %d), waiting %s before retrying", resp.StatusCode, wait)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, 0, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// decodePage 逐行解析一页搜索结果中的 items，返回解析结果和 total_count
+func (s *GitHubSource) decodePage(body []byte) (*decodeResult, int, error) {
+	var page struct {
+		TotalCount int             `json:"total_count"`
+		Items      json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, 0, newDecodeError("json", err, body)
+	}
+
+	result, err := decodeRows(FormatGitHubSearch, page.Items, decodeGitHubSearchRow)
+	if err != nil {
+		return nil, 0, newDecodeError("json", err, body)
+	}
+	if err := result.check(s.decodeMode, body); err != nil {
+		return nil, 0, err
+	}
+	return result, page.TotalCount, nil
+}
+
+// rateLimitWait 判断响应是否为限流，并计算需要等待的时间
+// 参考 X-RateLimit-Remaining / X-RateLimit-Reset 以及二级限流的 Retry-After；重置时间已过时返回 0，由调用方决定最短等待时间
+func (s *GitHubSource) rateLimitWait(resp *httpResponse) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	if wait := parseRetryAfter(resp.Header.Get("Retry-After"), s.now()); wait > 0 {
+		return wait, true
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+		if err != nil {
+			return githubMaxRateLimitWait + time.Second, true
+		}
+		wait := time.Unix(reset, 0).Sub(s.now())
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+
+	// 429 但没有限流头时短暂等待
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return githubMinRateLimitWait, true
+	}
+
+	return 0, false
+}
+
+// buildQuery 构建 GitHub 搜索条件
+func (s *GitHubSource) buildQuery(language string, period string) string {
+	since := s.now().AddDate(0, 0, -PeriodDays(period))
+
+	terms := []string{
+		"created:>" + since.Format("2006-01-02"),
+		fmt.Sprintf("stars:>%d", s.minStars),
+	}
+
+	if language != "" && !strings.EqualFold(language, "all") {
+		if strings.Contains(language, " ") {
+			terms = append(terms, fmt.Sprintf("language:%q", language))
+		} else {
+			terms = append(terms, "language:"+language)
+		}
+	}
+
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGitHubSourceRateLimitBounded(t *testing.T) {
+	tests := []struct {
+		name   string
+		header map[string]string
+	}{
+		{"429 without headers", nil},
+		{"reset already passed", map[string]string{
+			"X-RateLimit-Remaining": "0",
+			"X-RateLimit-Reset":     strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10),
+		}},
+		{"negative retry-after", map[string]string{"Retry-After": "-5"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				for k, v := range tt.header {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(http.StatusTooManyRequests)
+			}))
+			defer server.Close()
+
+			source := NewGitHubSource(server.URL, "", 0, server.Client())
+			start := time.Now()
+			_, err := source.FetchTrending(context.Background(), "go", "daily", 10)
+
+			var rateLimitErr *RateLimitError
+			if !errors.As(err, &rateLimitErr) {
+				t.Fatalf("err = %v, want *RateLimitError", err)
+			}
+			if got := atomic.LoadInt32(&requests); got != githubMaxRateLimitWaits+1 {
+				t.Errorf("requests = %d, want %d", got, githubMaxRateLimitWaits+1)
+			}
+			if elapsed := time.Since(start); elapsed < githubMaxRateLimitWaits*githubMinRateLimitWait {
+				t.Errorf("elapsed = %s, want at least %s between requests", elapsed, githubMaxRateLimitWaits*githubMinRateLimitWait)
+			}
+		})
+	}
+}
+
+func TestGitHubSourceRateLimitLongWait(t *testing.T) {
+	var requests int32
+	reset := time.Now().Add(time.Hour).Unix()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	source := NewGitHubSource(server.URL, "", 0, server.Client())
+	_, err := source.FetchTrending(context.Background(), "go", "daily", 10)
+
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("err = %v, want *RateLimitError", err)
+	}
+	if rateLimitErr.Reset.Unix() != reset {
+		t.Errorf("Reset = %v, want %v", rateLimitErr.Reset.Unix(), reset)
+	}
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+}
@@ -0,0 +1,258 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 倒数排名融合（Reciprocal Rank Fusion）常数，越大则各数据源排名差异的影响越小
+const rrfK = 60
+
+// SourceRank 仓库在某个数据源中的排名
+type SourceRank struct {
+	Source string `json:"source"`
+	Rank   int    `json:"rank"`
+}
+
+// MultiSource 同时请求多个数据源，按 RepoID/FullName 去重合并
+// 合并后的排名使用倒数排名融合：score = Σ 1/(60+rank)，被多个数据源收录且排名靠前的仓库得分更高
+type MultiSource struct {
+	sources []TrendingSource
+}
+
+// NewMultiSource 创建多数据源合并的数据源
+// 合并时按名称区分各数据源的结果，名称重复时返回错误
+func NewMultiSource(sources ...TrendingSource) (*MultiSource, error) {
+	seen := make(map[string]bool, len(sources))
+	for _, source := range sources {
+		if seen[source.Name()] {
+			return nil, fmt.Errorf("duplicate source %s: each source can only be listed once", source.Name())
+		}
+		seen[source.Name()] = true
+	}
+	return &MultiSource{sources: sources}, nil
+}
+
+// Name 数据源名称，如 "ossinsight+github_trending"
+func (s *MultiSource) Name() string {
+	names := make([]string, 0, len(s.sources))
+	for _, source := range s.sources {
+		names = append(names, source.Name())
+	}
+	return strings.Join(names, "+")
+}
+
+// FetchTrending 并发请求所有数据源并合并结果
+// 部分数据源失败时使用其余数据源的结果，全部失败时返回错误
+func (s *MultiSource) FetchTrending(ctx context.Context, language string, period string, limit int) ([]Repository, error) {
+	type result struct {
+		repos  []Repository
+		origin Origin
+		err    error
+	}
+
+	results := make([]result, len(s.sources))
+	var wg sync.WaitGroup
+	for i, source := range s.sources {
+		wg.Add(1)
+		go func(i int, source TrendingSource) {
+			defer wg.Done()
+			repos, err := source.FetchTrending(WithOrigin(ctx, &results[i].origin), language, period, limit)
+			results[i].repos, results[i].err = repos, err
+		}(i, source)
+	}
+	wg.Wait()
+
+	lists := make(map[string][]Repository, len(s.sources))
+	order := make([]string, 0, len(s.sources))
+	origins := make([]string, 0, len(s.sources))
+	var errs []error
+	for i, source := range s.sources {
+		if results[i].err != nil {
+			log.Printf("Warning: source %s failed: %v", source.Name(), results[i].err)
+			errs = append(errs, fmt.Errorf("%s: %w", source.Name(), results[i].err))
+			continue
+		}
+		lists[source.Name()] = results[i].repos
+		order = append(order, source.Name())
+		origins = append(origins, originOf(&results[i].origin, source))
+	}
+
+	if len(order) == 0 {
+		return nil, &sourcesError{errs: errs}
+	}
+
+	// 部分数据源失败时合并结果的组成不同，记录实际参与合并的数据源
+	setOrigin(ctx, strings.Join(origins, "+"))
+	merged := MergeRepositories(order, lists)
+	if limit > 0 && len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged, nil
+}
+
+// sourcesError 所有数据源都失败，错误信息合并为一行
+type sourcesError struct {
+	errs []error
+}
+
+// Error 如 "all sources failed: ossinsight: ...; github: ..."
+func (e *sourcesError) Error() string {
+	msgs := make([]string, 0, len(e.errs))
+	for _, err := range e.errs {
+		msgs = append(msgs, err.Error())
+	}
+	return "all sources failed: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap 支持 errors.Is 和 errors.As
+func (e *sourcesError) Unwrap() []error {
+	return e.errs
+}
+
+// MergeRepositories 按 RepoID/FullName 合并多个数据源的结果
+// order 为数据源名称的优先顺序，字段冲突时以靠前的数据源为准
+// 合并后每个仓库的 Sources 记录收录它的数据源及排名，Rank 为融合后的排名
+func MergeRepositories(order []string, lists map[string][]Repository) []Repository {
+	var merged []Repository
+	var scores []float64
+	byID := make(map[int64]int)
+	byName := make(map[string]int)
+
+	for _, name := range order {
+		for i, repo := range lists[name] {
+			rank := repo.Rank
+			if rank <= 0 {
+				rank = i + 1
+			}
+
+			fullName := strings.ToLower(repoFullName(&repo))
+			idx, ok := -1, false
+			if repo.RepoID != 0 {
+				idx, ok = byID[repo.RepoID]
+			}
+			if !ok && fullName != "" {
+				idx, ok = byName[fullName]
+			}
+
+			if !ok {
+				repo.Sources = nil
+				merged = append(merged, repo)
+				scores = append(scores, 0)
+				idx = len(merged) - 1
+			} else {
+				mergeRepository(&merged[idx], &repo)
+			}
+
+			target := &merged[idx]
+			target.Sources = append(target.Sources, SourceRank{Source: name, Rank: rank})
+			scores[idx] += 1.0 / float64(rrfK+rank)
+
+			if target.RepoID != 0 {
+				byID[target.RepoID] = idx
+			}
+			if fullName != "" {
+				byName[fullName] = idx
+			}
+		}
+	}
+
+	indexes := make([]int, len(merged))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		return scores[indexes[a]] > scores[indexes[b]]
+	})
+
+	sorted := make([]Repository, 0, len(merged))
+	for _, idx := range indexes {
+		repo := merged[idx]
+		repo.Rank = len(sorted) + 1
+		sorted = append(sorted, repo)
+	}
+
+	return sorted
+}
+
+// mergeRepository 用另一个数据源的数据补全缺失字段
+func mergeRepository(dst, src *Repository) {
+	if dst.RepoID == 0 {
+		dst.RepoID = src.RepoID
+	}
+	if dst.RepoName == "" {
+		dst.RepoName = src.RepoName
+	}
+	if dst.FullName == "" {
+		dst.FullName = src.FullName
+	}
+	if dst.Description == "" {
+		dst.Description = src.Description
+	}
+	if dst.Language == "" {
+		dst.Language = src.Language
+	}
+	if dst.Owner == "" {
+		dst.Owner = src.Owner
+	}
+	if dst.URL == "" {
+		dst.URL = src.URL
+	}
+	if dst.HTMLURL == "" {
+		dst.HTMLURL = src.HTMLURL
+	}
+	if dst.Stars == 0 {
+		dst.Stars = src.Stars
+	}
+	if dst.StargazersCount == 0 {
+		dst.StargazersCount = src.StargazersCount
+	}
+	if dst.Forks == 0 {
+		dst.Forks = src.Forks
+	}
+	if dst.ForksCount == 0 {
+		dst.ForksCount = src.ForksCount
+	}
+	if dst.StarsDelta == 0 {
+		dst.StarsDelta = src.StarsDelta
+	}
+	if dst.ForksDelta == 0 {
+		dst.ForksDelta = src.ForksDelta
+	}
+	if dst.Pushes == 0 {
+		dst.Pushes = src.Pushes
+	}
+	if dst.PullRequests == 0 {
+		dst.PullRequests = src.PullRequests
+	}
+	if len(dst.BuiltBy) == 0 {
+		dst.BuiltBy = src.BuiltBy
+	}
+	if dst.TotalScore == 0 {
+		dst.TotalScore = src.TotalScore
+	}
+	if len(dst.Contributors) == 0 {
+		dst.Contributors = src.Contributors
+	}
+	if len(dst.Collections) == 0 {
+		dst.Collections = src.Collections
+	}
+	if len(dst.Topics) == 0 {
+		dst.Topics = src.Topics
+	}
+	if !dst.Archived {
+		dst.Archived = src.Archived
+	}
+}
+
+// repoFullName 仓库全名，兼容只设置了 RepoName 的数据
+func repoFullName(repo *Repository) string {
+	if repo.FullName != "" {
+		return repo.FullName
+	}
+	return repo.RepoName
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// OSSInsight 默认地址
+const DefaultOSSInsightBaseURL = "https://api.ossinsight.io"
+
+// OSSInsight trending 接口路径
+const ossInsightTrendingPath = "/v1/trends/repos/"
+
+// OSSInsightSource OSSInsight trending 数据源
+type OSSInsightSource struct {
+	baseURL    string
+	httpClient *http.Client
+	decodeMode DecodeMode
+}
+
+// NewOSSInsightSource 创建 OSSInsight 数据源
+// baseURL 为空时使用 DefaultOSSInsightBaseURL，可指向镜像、代理或本地测试服务
+func NewOSSInsightSource(baseURL string, httpClient *http.Client) *OSSInsightSource {
+	if baseURL == "" {
+		baseURL = DefaultOSSInsightBaseURL
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &OSSInsightSource{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: httpClient,
+		decodeMode: DecodeLenient,
+	}
+}
+
+// Name 数据源名称
+func (s *OSSInsightSource) Name() string {
+	return SourceOSSInsight
+}
+
+// FetchTrending 获取 OSSInsight trending 仓库
+func (s *OSSInsightSource) FetchTrending(ctx context.Context, language string, period string, limit int) ([]Repository, error) {
+	apiURL, err := s.buildTrendingURL(language, period)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build URL: %w", err)
+	}
+
+	body, err := fetchBody(ctx, s.httpClient, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseTrendingResponse(ctx, s.Name(), body, limit, s.decodeMode)
+}
+
+// buildTrendingURL 构建trending API URL
+func (s *OSSInsightSource) buildTrendingURL(language string, period string) (string, error) {
+	// 使用 OSSInsight Trending API 获取真正的 trending 仓库
+	// 该 API 返回指定时间段内 star 增长最快的项目
+	endpoint := s.baseURL + ossInsightTrendingPath
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	// 映射 period 参数到 OSSInsight API 格式
+	var ossinsightPeriod string
+	switch period {
+	case "daily", "past_day", "past_24_hours":
+		ossinsightPeriod = "past_24_hours"
+	case "weekly", "past_7_days", "past_week":
+		ossinsightPeriod = "past_week"
+	case "monthly", "past_month", "past_28_days":
+		ossinsightPeriod = "past_month"
+	case "past_3_months":
+		ossinsightPeriod = "past_3_months"
+	default:
+		ossinsightPeriod = "past_week" // 默认一周
+	}
+
+	// 映射 language 参数 - OSSInsight 使用首字母大写
+	var ossinsightLanguage string
+	if language == "" || language == "all" {
+		ossinsightLanguage = "All"
+	} else {
+		// 将首字母大写（如 "go" -> "Go", "javascript" -> "JavaScript"）
+		ossinsightLanguage = strings.ToUpper(language[:1]) + strings.ToLower(language[1:])
+		// 特殊处理常见语言名称
+		switch strings.ToLower(language) {
+		case "javascript":
+			ossinsightLanguage = "JavaScript"
+		case "typescript":
+			ossinsightLanguage = "TypeScript"
+		case "c++":
+			ossinsightLanguage = "C++"
+		case "c#":
+			ossinsightLanguage = "C#"
+		case "php":
+			ossinsightLanguage = "PHP"
+		case "html":
+			ossinsightLanguage = "HTML"
+		case "css":
+			ossinsightLanguage = "CSS"
+		case "plpgsql":
+			ossinsightLanguage = "PLpgSQL"
+		case "tsql":
+			ossinsightLanguage = "TSQL"
+		case "hcl":
+			ossinsightLanguage = "HCL"
+		case "cmake":
+			ossinsightLanguage = "CMake"
+		case "powershell":
+			ossinsightLanguage = "PowerShell"
+		case "matlab":
+			ossinsightLanguage = "MATLAB"
+		case "objective-c":
+			ossinsightLanguage = "Objective-C"
+		}
+	}
+
+	q := u.Query()
+	q.Set("period", ossinsightPeriod)
+	q.Set("language", ossinsightLanguage)
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy 请求失败时的重试策略：指数退避加随机抖动，优先使用响应中的 Retry-After
+type RetryPolicy struct {
+	MaxAttempts     int           // 最多请求次数（包括第一次），小于等于 1 表示不重试
+	InitialInterval time.Duration // 第一次重试前的等待时间
+	MaxInterval     time.Duration // 单次等待时间的上限（不限制 Retry-After）
+	Multiplier      float64       // 每次重试后等待时间的倍数
+	MaxElapsed      time.Duration // 从第一次请求开始的总时长上限，下一次重试会超出时不再重试，0 表示不限制
+}
+
+// DefaultRetryPolicy 默认重试策略：最多 4 次请求，等待约 1s、2s、4s，总时长不超过 2 分钟
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:     4,
+	InitialInterval: time.Second,
+	MaxInterval:     30 * time.Second,
+	Multiplier:      2,
+	MaxElapsed:      2 * time.Minute,
+}
+
+// RetrySource 按重试策略重试临时性失败（网络错误、408、RateLimitError 和 ServerError）的数据源
+type RetrySource struct {
+	source TrendingSource
+	policy RetryPolicy
+	now    func() time.Time
+	jitter func() float64 // 返回 [0, 1) 的随机数
+}
+
+// NewRetrySource 创建带重试的数据源
+func NewRetrySource(source TrendingSource, policy RetryPolicy) *RetrySource {
+	if policy.Multiplier < 1 {
+		policy.Multiplier = 1
+	}
+	return &RetrySource{
+		source: source,
+		policy: policy,
+		now:    time.Now,
+		jitter: rand.Float64,
+	}
+}
+
+// Name 数据源名称
+func (s *RetrySource) Name() string {
+	return s.source.Name()
+}
+
+// FetchTrending 请求数据源，临时性失败时等待后重试，每次失败和重试都会记录日志
+func (s *RetrySource) FetchTrending(ctx context.Context, language string, period string, limit int) ([]Repository, error) {
+	maxAttempts := s.policy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	start := s.now()
+	for attempt := 1; ; attempt++ {
+		repos, err := s.source.FetchTrending(ctx, language, period, limit)
+		if err == nil {
+			if attempt > 1 {
+				log.Printf("Source %s succeeded on attempt %d/%d", s.Name(), attempt, maxAttempts)
+			}
+			return repos, nil
+		}
+		if ctx.Err() != nil || !IsRetryable(err) {
+			return nil, err
+		}
+		if attempt >= maxAttempts {
+			if attempt > 1 {
+				return nil, fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+			}
+			return nil, err
+		}
+
+		delay := s.backoff(attempt, err)
+		elapsed := s.now().Sub(start)
+		if s.policy.MaxElapsed > 0 && elapsed+delay > s.policy.MaxElapsed {
+			log.Printf("Attempt %d/%d to fetch from %s failed: %v; next retry in %s would exceed max elapsed time %s",
+				attempt, maxAttempts, s.Name(), err, delay.Round(time.Millisecond), s.policy.MaxElapsed)
+			return nil, fmt.Errorf("giving up after %d attempts in %s: %w", attempt, elapsed.Round(time.Millisecond), err)
+		}
+		log.Printf("Attempt %d/%d to fetch from %s failed: %v; retrying in %s",
+			attempt, maxAttempts, s.Name(), err, delay.Round(time.Millisecond))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff 第 attempt 次失败后的等待时间
+// 响应带有 Retry-After 时按其等待，否则为 InitialInterval * Multiplier^(attempt-1)，
+// 不超过 MaxInterval，并在后一半区间内随机抖动，避免多个客户端同时重试
+func (s *RetrySource) backoff(attempt int, err error) time.Duration {
+	var rateLimitErr *RateLimitError
+	if errors.As(err, &rateLimitErr) && rateLimitErr.RetryAfter > 0 {
+		return rateLimitErr.RetryAfter
+	}
+	var serverErr *ServerError
+	if errors.As(err, &serverErr) && serverErr.RetryAfter > 0 {
+		return serverErr.RetryAfter
+	}
+
+	delay := float64(s.policy.InitialInterval) * math.Pow(s.policy.Multiplier, float64(attempt-1))
+	if s.policy.MaxInterval > 0 && delay > float64(s.policy.MaxInterval) {
+		delay = float64(s.policy.MaxInterval)
+	}
+	return time.Duration(delay/2 + s.jitter()*delay/2)
+}
+
+// IsRetryable 判断错误是否为临时性失败：网络错误、响应读取中断、408、429 和 5xx（501 除外）
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+
+	var rateLimitErr *RateLimitError
+	var serverErr *ServerError
+	var clientErr *ClientError
+	switch {
+	case errors.As(err, &rateLimitErr):
+		return true
+	case errors.As(err, &serverErr):
+		return serverErr.StatusCode != http.StatusNotImplemented
+	case errors.As(err, &clientErr):
+		return clientErr.StatusCode == http.StatusRequestTimeout
+	case errors.As(err, new(*NotFoundError)), errors.As(err, new(*DecodeError)):
+		return false
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 数据源名称
+const (
+	SourceOSSInsight     = "ossinsight"
+	SourceGitHub         = "github"
+	SourceGitHubTrending = "github_trending"
+)
+
+// TrendingSource trending 数据源
+type TrendingSource interface {
+	// Name 数据源名称，用于日志和出处标记
+	Name() string
+	// FetchTrending 获取指定语言和时间范围的 trending 仓库，最多返回 limit 个
+	FetchTrending(ctx context.Context, language string, period string, limit int) ([]Repository, error)
+}
+
+// SourceOptions 创建数据源的参数
+type SourceOptions struct {
+	BaseURL string        // OSSInsight 地址，为空时使用默认地址
+	Timeout time.Duration // 请求超时时间
+	GitHub  GitHubOptions // GitHub Search API 参数
+	WebURL  string        // github.com 地址，用于 github_trending 数据源，为空时使用默认地址
+	// DecodeMode OSSInsight 和 GitHub Search 响应中出现无法解析的数据行时的处理方式，为空时使用 DecodeLenient
+	DecodeMode DecodeMode
+}
+
+// GitHubOptions GitHub Search API 数据源参数
+type GitHubOptions struct {
+	BaseURL  string // GitHub API 地址，为空时使用 DefaultGitHubBaseURL
+	Token    string // 访问令牌，可选，用于提高限流额度
+	MinStars int    // 最低 star 数
+}
+
+// NewSource 按名称创建数据源
+func NewSource(name string, opts SourceOptions) (TrendingSource, error) {
+	httpClient := &http.Client{
+		Timeout: opts.Timeout,
+	}
+
+	switch strings.ToLower(name) {
+	case "", SourceOSSInsight:
+		source := NewOSSInsightSource(opts.BaseURL, httpClient)
+		if opts.DecodeMode != "" {
+			source.decodeMode = opts.DecodeMode
+		}
+		return source, nil
+	case SourceGitHub:
+		source := NewGitHubSource(opts.GitHub.BaseURL, opts.GitHub.Token, opts.GitHub.MinStars, httpClient)
+		if opts.DecodeMode != "" {
+			source.decodeMode = opts.DecodeMode
+		}
+		return source, nil
+	case SourceGitHubTrending:
+		return NewTrendingPageSource(opts.WebURL, httpClient), nil
+	default:
+		return nil, fmt.Errorf("unknown trending source: %s", name)
+	}
+}
+
+// PeriodDays 时间范围对应的天数，如 daily 为 1、weekly 为 7
+func PeriodDays(period string) int {
+	switch period {
+	case "daily", "past_day", "past_24_hours":
+		return 1
+	case "monthly", "past_month", "past_28_days":
+		return 30
+	case "past_3_months":
+		return 90
+	default:
+		return 7
+	}
+}
+
+// FallbackSource 主数据源失败时自动切换到备用数据源
+type FallbackSource struct {
+	primary  TrendingSource
+	fallback TrendingSource
+}
+
+// NewFallbackSource 创建带备用数据源的数据源
+func NewFallbackSource(primary, fallback TrendingSource) *FallbackSource {
+	return &FallbackSource{
+		primary:  primary,
+		fallback: fallback,
+	}
+}
+
+// Name 数据源名称
+func (s *FallbackSource) Name() string {
+	return s.primary.Name()
+}
+
+// FetchTrending 先请求主数据源，失败时请求备用数据源，并通过 Origin 记录实际返回结果的数据源
+func (s *FallbackSource) FetchTrending(ctx context.Context, language string, period string, limit int) ([]Repository, error) {
+	repos, err := s.primary.FetchTrending(ctx, language, period, limit)
+	if err == nil {
+		return repos, nil
+	}
+
+	log.Printf("Warning: source %s failed (%v), falling back to %s", s.primary.Name(), err, s.fallback.Name())
+
+	var origin Origin
+	repos, fallbackErr := s.fallback.FetchTrending(WithOrigin(ctx, &origin), language, period, limit)
+	if fallbackErr != nil {
+		return nil, fmt.Errorf("source %s failed: %w; fallback %s failed: %w",
+			s.primary.Name(), err, s.fallback.Name(), fallbackErr)
+	}
+	setOrigin(ctx, originOf(&origin, s.fallback))
+	return repos, nil
+}
+
+// Origin 记录一次抓取实际返回结果的数据源
+// 配置了备用数据源或多个数据源时，结果不一定来自 TrendingSource.Name() 对应的数据源
+type Origin struct {
+	mu     sync.Mutex
+	source string
+}
+
+// Source 返回结果的数据源，为空表示结果来自抓取时使用的数据源本身
+func (o *Origin) Source() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.source
+}
+
+type originKey struct{}
+
+// WithOrigin 返回携带 origin 的 context，使用该 context 抓取时数据源把实际返回结果的数据源记录到 origin 中
+func WithOrigin(ctx context.Context, origin *Origin) context.Context {
+	return context.WithValue(ctx, originKey{}, origin)
+}
+
+// setOrigin 记录返回结果的数据源
+// 数据源嵌套时最内层最先记录，外层不再覆盖
+func setOrigin(ctx context.Context, source string) {
+	origin, ok := ctx.Value(originKey{}).(*Origin)
+	if !ok {
+		return
+	}
+	origin.mu.Lock()
+	defer origin.mu.Unlock()
+	if origin.source == "" {
+		origin.source = source
+	}
+}
+
+// originOf 返回 origin 中记录的数据源，没有记录时返回 source 的名称
+func originOf(origin *Origin, source TrendingSource) string {
+	if name := origin.Source(); name != "" {
+		return name
+	}
+	return source.Name()
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeSource 返回固定结果的数据源
+type fakeSource struct {
+	name  string
+	repos []Repository
+	err   error
+	calls int32
+}
+
+func (s *fakeSource) Name() string {
+	return s.name
+}
+
+func (s *fakeSource) FetchTrending(ctx context.Context, language string, period string, limit int) ([]Repository, error) {
+	atomic.AddInt32(&s.calls, 1)
+	if s.err != nil {
+		return nil, s.err
+	}
+	repos := append([]Repository(nil), s.repos...)
+	if limit > 0 && len(repos) > limit {
+		repos = repos[:limit]
+	}
+	return repos, nil
+}
+
+func repoNames(repos []Repository) []string {
+	names := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		names = append(names, repo.FullName)
+	}
+	return names
+}
+
+func TestFallbackSource(t *testing.T) {
+	ok := &fakeSource{name: "secondary", repos: []Repository{{FullName: "a/one"}}}
+
+	t.Run("primary succeeds", func(t *testing.T) {
+		primary := &fakeSource{name: "primary", repos: []Repository{{FullName: "p/one"}}}
+		fallback := &fakeSource{name: "secondary"}
+		repos, err := NewFallbackSource(primary, fallback).FetchTrending(context.Background(), "go", "daily", 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := repoNames(repos); !reflect.DeepEqual(got, []string{"p/one"}) {
+			t.Errorf("repos = %v", got)
+		}
+		if fallback.calls != 0 {
+			t.Errorf("fallback called %d times, want 0", fallback.calls)
+		}
+	})
+
+	t.Run("primary fails", func(t *testing.T) {
+		primary := &fakeSource{name: "primary", err: &ServerError{StatusCode: 502}}
+		repos, err := NewFallbackSource(primary, ok).FetchTrending(context.Background(), "go", "daily", 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := repoNames(repos); !reflect.DeepEqual(got, []string{"a/one"}) {
+			t.Errorf("repos = %v", got)
+		}
+	})
+
+	t.Run("chain fails", func(t *testing.T) {
+		primary := &fakeSource{name: "primary", err: &ServerError{StatusCode: 502}}
+		second := &fakeSource{name: "secondary", err: &NotFoundError{StatusCode: 404}}
+		third := &fakeSource{name: "third", err: &RateLimitError{StatusCode: 429}}
+		source := NewFallbackSource(primary, NewFallbackSource(second, third))
+
+		_, err := source.FetchTrending(context.Background(), "go", "daily", 10)
+		var serverErr *ServerError
+		var notFoundErr *NotFoundError
+		var rateLimitErr *RateLimitError
+		if !errors.As(err, &serverErr) || !errors.As(err, &notFoundErr) || !errors.As(err, &rateLimitErr) {
+			t.Errorf("err = %v, want it to wrap every source's error", err)
+		}
+		if source.Name() != "primary" {
+			t.Errorf("Name() = %q, want primary", source.Name())
+		}
+	})
+}
+
+func TestMultiSourceMerge(t *testing.T) {
+	first := &fakeSource{name: "first", repos: []Repository{
+		{RepoID: 1, FullName: "a/one"},
+		{RepoID: 2, FullName: "b/two", Stars: 100},
+		{RepoID: 3, FullName: "c/three"},
+	}}
+	second := &fakeSource{name: "second", repos: []Repository{
+		{FullName: "C/Three", Description: "from second", StarsDelta: 9},
+		{FullName: "d/four"},
+		{FullName: "b/two", Stars: 1},
+	}}
+	failing := &fakeSource{name: "failing", err: &ServerError{StatusCode: 503}}
+
+	source, err := NewMultiSource(first, second, failing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repos, err := source.FetchTrending(context.Background(), "go", "daily", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// c/three 和 b/two 被两个数据源收录，排在只被一个数据源收录的仓库之前
+	if got, want := repoNames(repos), []string{"c/three", "b/two", "a/one"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("repos = %v, want %v", got, want)
+	}
+	three := repos[0]
+	if three.Rank != 1 || three.Description != "from second" || three.StarsDelta != 9 {
+		t.Errorf("c/three = %+v, want rank 1 with fields filled from second", three)
+	}
+	if want := []SourceRank{{Source: "first", Rank: 3}, {Source: "second", Rank: 1}}; !reflect.DeepEqual(three.Sources, want) {
+		t.Errorf("c/three Sources = %v, want %v", three.Sources, want)
+	}
+	if repos[1].Stars != 100 {
+		t.Errorf("b/two Stars = %d, want 100 from the first source", repos[1].Stars)
+	}
+}
+
+func TestMultiSourceAllFail(t *testing.T) {
+	source, err := NewMultiSource(
+		&fakeSource{name: "first", err: &ServerError{StatusCode: 502}},
+		&fakeSource{name: "second", err: &DecodeError{Format: "html", Err: errors.New("no repositories")}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = source.FetchTrending(context.Background(), "go", "daily", 10)
+	var serverErr *ServerError
+	var decodeErr *DecodeError
+	if !errors.As(err, &serverErr) || !errors.As(err, &decodeErr) {
+		t.Errorf("err = %v, want it to wrap both sources' errors", err)
+	}
+}
+
+func TestMultiSourceRejectsDuplicateNames(t *testing.T) {
+	_, err := NewMultiSource(
+		&fakeSource{name: SourceOSSInsight, repos: []Repository{{FullName: "a/one"}}},
+		&fakeSource{name: SourceGitHub},
+		&fakeSource{name: SourceOSSInsight, repos: []Repository{{FullName: "b/two"}}},
+	)
+	if err == nil {
+		t.Fatal("NewMultiSource accepted two sources named ossinsight")
+	}
+}
+
+func TestOriginRecordsAnsweringSource(t *testing.T) {
+	failing := &fakeSource{name: "primary", err: &ServerError{StatusCode: 502}}
+	ok := &fakeSource{name: "secondary", repos: []Repository{{FullName: "a/one"}}}
+	third := &fakeSource{name: "third", repos: []Repository{{FullName: "b/two"}}}
+	multi, err := NewMultiSource(ok, failing, third)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		source TrendingSource
+		want   string
+	}{
+		{"plain source", ok, ""},
+		{"primary succeeds", NewFallbackSource(ok, third), ""},
+		{"fallback succeeds", NewFallbackSource(failing, ok), "secondary"},
+		{"nested fallback", NewFallbackSource(failing, NewFallbackSource(&fakeSource{name: "x", err: errors.New("down")}, third)), "third"},
+		{"multi partial", multi, "secondary+third"},
+		{"fallback to multi", NewFallbackSource(failing, multi), "secondary+third"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var origin Origin
+			if _, err := tt.source.FetchTrending(WithOrigin(context.Background(), &origin), "go", "daily", 10); err != nil {
+				t.Fatal(err)
+			}
+			if got := origin.Source(); got != tt.want {
+				t.Errorf("origin = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// github.com 默认地址
+const DefaultGitHubWebURL = "https://github.com"
+
+// github.com/trending 页面每次最多展示的仓库数量
+const trendingPageMaxRepos = 25
+
+var (
+	trendingArticleRe  = regexp.MustCompile(`<article[^>]*class="[^"]*Box-row[^"]*"`)
+	trendingNameRe     = regexp.MustCompile(`(?s)<h[12][^>]*>.*?<a[^>]*href="/([^"/?#]+/[^"/?#]+)"`)
+	trendingDescRe     = regexp.MustCompile(`(?s)<p[^>]*class="[^"]*col-9[^"]*"[^>]*>(.*?)</p>`)
+	trendingLangRe     = regexp.MustCompile(`itemprop="programmingLanguage"[^>]*>([^<]*)<`)
+	trendingStarsRe    = regexp.MustCompile(`(?s)<a[^>]*href="/[^"]+/stargazers"[^>]*>(.*?)</a>`)
+	trendingForksRe    = regexp.MustCompile(`(?s)<a[^>]*href="/[^"]+/(?:forks|network/members)"[^>]*>(.*?)</a>`)
+	trendingPeriodRe   = regexp.MustCompile(`([\d,]+)\s+stars?\s+(?:today|this week|this month)`)
+	trendingAvatarRe   = regexp.MustCompile(`<img[^>]*class="[^"]*avatar[^"]*"[^>]*>`)
+	trendingAltRe      = regexp.MustCompile(`alt="@?([^"]*)"`)
+	trendingSrcRe      = regexp.MustCompile(`src="([^"]*)"`)
+	trendingTagRe      = regexp.MustCompile(`(?s)<[^>]*>`)
+	trendingSpaceRe    = regexp.MustCompile(`\s+`)
+	trendingNonDigitRe = regexp.MustCompile(`[^\d]`)
+)
+
+// TrendingPageSource github.com/trending 页面数据源
+// 排名与用户在网页上看到的一致，"stars today/this week/this month" 会写入 StarsDelta
+type TrendingPageSource struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewTrendingPageSource 创建 github.com/trending 页面数据源
+// baseURL 为空时使用 DefaultGitHubWebURL，可指向保存了页面的本地服务
+func NewTrendingPageSource(baseURL string, httpClient *http.Client) *TrendingPageSource {
+	if baseURL == "" {
+		baseURL = DefaultGitHubWebURL
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &TrendingPageSource{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// Name 数据源名称
+func (s *TrendingPageSource) Name() string {
+	return SourceGitHubTrending
+}
+
+// FetchTrending 抓取并解析 github.com/trending 页面
+func (s *TrendingPageSource) FetchTrending(ctx context.Context, language string, period string, limit int) ([]Repository, error) {
+	header := http.Header{}
+	header.Set("Accept", "text/html")
+
+	body, err := fetchBody(ctx, s.httpClient, s.buildPageURL(language, period), header)
+	if err != nil {
+		return nil, err
+	}
+
+	repos, err := ParseTrendingHTML(strings.NewReader(string(body)), s.baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit > 0 && len(repos) > limit {
+		repos = repos[:limit]
+	}
+	return repos, nil
+}
+
+// buildPageURL 构建 trending 页面地址，如 https://github.com/trending/go?since=daily
+func (s *TrendingPageSource) buildPageURL(language string, period string) string {
+	pageURL := s.baseURL + "/trending"
+	if language != "" && !strings.EqualFold(language, "all") {
+		slug := strings.ReplaceAll(strings.ToLower(language), " ", "-")
+		pageURL += "/" + url.PathEscape(slug)
+	}
+
+	var since string
+	switch period {
+	case "daily", "past_day", "past_24_hours":
+		since = "daily"
+	case "monthly", "past_month", "past_28_days":
+		since = "monthly"
+	default:
+		since = "weekly"
+	}
+
+	return pageURL + "?since=" + since
+}
+
+// ParseTrendingHTML 解析 github.com/trending 页面 HTML
+// baseURL 用于拼接仓库地址，为空时使用 DefaultGitHubWebURL
+// 页面结构变化导致解析不出任何仓库时返回 DecodeError，而不是空列表
+func ParseTrendingHTML(r io.Reader, baseURL string) ([]Repository, error) {
+	if baseURL == "" {
+		baseURL = DefaultGitHubWebURL
+	}
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trending page: %w", err)
+	}
+	page := string(data)
+
+	// 按 <article class="Box-row"> 切分，每段对应一个仓库
+	starts := trendingArticleRe.FindAllStringIndex(page, -1)
+	if len(starts) == 0 {
+		return nil, newDecodeError("html", errors.New("no repositories found on trending page"), data)
+	}
+
+	repos := make([]Repository, 0, len(starts))
+	for i, loc := range starts {
+		end := len(page)
+		if i+1 < len(starts) {
+			end = starts[i+1][0]
+		}
+		block := page[loc[0]:end]
+
+		m := trendingNameRe.FindStringSubmatch(block)
+		if m == nil {
+			continue
+		}
+		fullName := m[1]
+
+		repo := Repository{
+			RepoName: fullName,
+			FullName: fullName,
+			Rank:     len(repos) + 1,
+			URL:      baseURL + "/" + fullName,
+			HTMLURL:  baseURL + "/" + fullName,
+		}
+		if idx := strings.Index(fullName, "/"); idx > 0 {
+			repo.Owner = fullName[:idx]
+		}
+
+		if m := trendingDescRe.FindStringSubmatch(block); m != nil {
+			repo.Description = cleanHTMLText(m[1])
+		}
+		if m := trendingLangRe.FindStringSubmatch(block); m != nil {
+			repo.Language = cleanHTMLText(m[1])
+		}
+		if m := trendingStarsRe.FindStringSubmatch(block); m != nil {
+			repo.Stars = parseHTMLCount(m[1])
+			repo.StargazersCount = repo.Stars
+		}
+		if m := trendingForksRe.FindStringSubmatch(block); m != nil {
+			repo.Forks = parseHTMLCount(m[1])
+			repo.ForksCount = repo.Forks
+		}
+		if m := trendingPeriodRe.FindStringSubmatch(block); m != nil {
+			repo.StarsDelta = parseHTMLCount(m[1])
+		}
+
+		for _, img := range trendingAvatarRe.FindAllString(block, -1) {
+			var contributor Contributor
+			if m := trendingAltRe.FindStringSubmatch(img); m != nil {
+				contributor.Login = html.UnescapeString(m[1])
+			}
+			if m := trendingSrcRe.FindStringSubmatch(img); m != nil {
+				contributor.AvatarURL = html.UnescapeString(m[1])
+			}
+			if contributor.Login != "" {
+				repo.BuiltBy = append(repo.BuiltBy, contributor)
+			}
+		}
+
+		repos = append(repos, repo)
+		if len(repos) >= trendingPageMaxRepos {
+			break
+		}
+	}
+
+	// 找到了仓库区块但解析不出任何仓库名，说明页面结构已经变化
+	if len(repos) == 0 {
+		return nil, newDecodeError("html", fmt.Errorf("found %d repository blocks on trending page but none had a repository link", len(starts)), data)
+	}
+	return repos, nil
+}
+
+// cleanHTMLText 去除标签、反转义实体并合并空白
+func cleanHTMLText(s string) string {
+	s = trendingTagRe.ReplaceAllString(s, " ")
+	s = html.UnescapeString(s)
+	return strings.TrimSpace(trendingSpaceRe.ReplaceAllString(s, " "))
+}
+
+// parseHTMLCount 解析页面中带千位分隔符的数字，如 "12,345"
+func parseHTMLCount(s string) int {
+	digits := trendingNonDigitRe.ReplaceAllString(cleanHTMLText(s), "")
+	n, _ := strconv.Atoi(digits)
+	return n
+}
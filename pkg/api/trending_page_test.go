@@ -0,0 +1,125 @@
+package api
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTrendingHTML(t *testing.T) {
+	f, err := os.Open("testdata/trending_go_daily.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	repos, err := ParseTrendingHTML(f, "")
+	if err != nil {
+		t.Fatalf("ParseTrendingHTML: %v", err)
+	}
+
+	want := []struct {
+		fullName    string
+		stars       int
+		forks       int
+		starsDelta  int
+		language    string
+		description string
+		builtBy     []Contributor
+	}{
+		{
+			fullName:    "charmbracelet/bubbletea",
+			stars:       29417,
+			forks:       826,
+			starsDelta:  1204,
+			language:    "Go",
+			description: "A powerful little TUI framework 🏗",
+			builtBy: []Contributor{
+				{Login: "meowgorithm", AvatarURL: "https://avatars.githubusercontent.com/u/25087?s=40&v=4"},
+				{Login: "aymanbagabas", AvatarURL: "https://avatars.githubusercontent.com/u/3187948?s=40&v=4"},
+			},
+		},
+		{
+			fullName:    "golang/go",
+			stars:       125980,
+			forks:       17843,
+			starsDelta:  88,
+			language:    "Go",
+			description: "The Go programming language",
+			builtBy: []Contributor{
+				{Login: "rsc", AvatarURL: "https://avatars.githubusercontent.com/u/104030?s=40&v=4"},
+			},
+		},
+		{
+			fullName:   "acme/new-tool",
+			stars:      1,
+			starsDelta: 1,
+		},
+	}
+
+	if len(repos) != len(want) {
+		t.Fatalf("got %d repositories, want %d", len(repos), len(want))
+	}
+	for i, w := range want {
+		repo := repos[i]
+		if repo.FullName != w.fullName {
+			t.Errorf("repo %d FullName = %q, want %q", i, repo.FullName, w.fullName)
+		}
+		if repo.Rank != i+1 {
+			t.Errorf("%s Rank = %d, want %d", w.fullName, repo.Rank, i+1)
+		}
+		if repo.Stars != w.stars || repo.StargazersCount != w.stars {
+			t.Errorf("%s Stars = %d/%d, want %d", w.fullName, repo.Stars, repo.StargazersCount, w.stars)
+		}
+		if repo.Forks != w.forks {
+			t.Errorf("%s Forks = %d, want %d", w.fullName, repo.Forks, w.forks)
+		}
+		if repo.StarsDelta != w.starsDelta {
+			t.Errorf("%s StarsDelta = %d, want %d", w.fullName, repo.StarsDelta, w.starsDelta)
+		}
+		if repo.Language != w.language {
+			t.Errorf("%s Language = %q, want %q", w.fullName, repo.Language, w.language)
+		}
+		if repo.Description != w.description {
+			t.Errorf("%s Description = %q, want %q", w.fullName, repo.Description, w.description)
+		}
+		if !reflect.DeepEqual(repo.BuiltBy, w.builtBy) {
+			t.Errorf("%s BuiltBy = %+v, want %+v", w.fullName, repo.BuiltBy, w.builtBy)
+		}
+		if wantURL := "https://github.com/" + w.fullName; repo.URL != wantURL {
+			t.Errorf("%s URL = %q, want %q", w.fullName, repo.URL, wantURL)
+		}
+	}
+}
+
+func TestParseTrendingHTMLMarkupChanged(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/trending_go_daily.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := string(fixture)
+
+	tests := []struct {
+		name string
+		page string
+	}{
+		{"article class renamed", strings.ReplaceAll(page, `class="Box-row"`, `class="TrendingRow"`)},
+		{"repository heading changed", strings.NewReplacer(`<h2 class="h3 lh-condensed">`, `<div class="repo-title">`, `</h2>`, `</div>`).Replace(page)},
+		{"empty page", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos, err := ParseTrendingHTML(strings.NewReader(tt.page), "")
+			var decodeErr *DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("got %d repositories and err = %v, want *DecodeError", len(repos), err)
+			}
+			if decodeErr.Format != "html" {
+				t.Errorf("Format = %q, want html", decodeErr.Format)
+			}
+		})
+	}
+}
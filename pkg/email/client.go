@@ -1,55 +1,88 @@
 package email
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
 	"fmt"
-	"net/smtp"
-	"strings"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"time"
 )
 
 // Client 邮件客户端
 type Client struct {
-	smtpHost string
-	smtpPort int
-	username string
-	password string
-	from     string
+	smtpHost  string
+	smtpPort  int
+	username  string
+	password  string
+	from      string
+	tlsMode   string        // TLS 模式，见 TLSStartTLS 等常量
+	tlsConfig *tls.Config   // STARTTLS 和 implicit TLS 使用的配置
+	auth      string        // 认证方式，见 AuthPlain 等常量
+	timeout   time.Duration // 连接和发送一封邮件的超时时间
 }
 
-// NewClient 创建邮件客户端
+// NewClient 创建邮件客户端，服务器支持时使用 STARTTLS，使用 AUTH PLAIN 认证
 func NewClient(smtpHost string, smtpPort int, username, password, from string) *Client {
 	return &Client{
-		smtpHost: smtpHost,
-		smtpPort: smtpPort,
-		username: username,
-		password: password,
-		from:     from,
+		smtpHost:  smtpHost,
+		smtpPort:  smtpPort,
+		username:  username,
+		password:  password,
+		from:      from,
+		tlsMode:   TLSStartTLS,
+		tlsConfig: &tls.Config{ServerName: smtpHost, MinVersion: tls.VersionTLS12},
+		auth:      AuthPlain,
+		timeout:   defaultTimeout,
 	}
 }
 
 // Message 邮件消息
+// 地址可以带显示名称，如 "张三 <zhangsan@example.com>"
 type Message struct {
-	To      []string
-	Subject string
-	Body    string
-	IsHTML  bool
+	To              []string
+	Cc              []string
+	Bcc             []string // 密送，不出现在邮件头中
+	ReplyTo         []string
+	Subject         string
+	Body            string
+	IsHTML          bool
+	TextBody        string   // HTML 邮件的纯文本版本，不为空时与 Body 一起以 multipart/alternative 发送
+	ListUnsubscribe []string // 退订地址，如 "mailto:unsubscribe@example.com" 或 https 链接，写入 List-Unsubscribe 邮件头
 }
 
 // Send 发送邮件
 func (c *Client) Send(msg *Message) error {
+	return c.SendContext(context.Background(), msg)
+}
+
+// SendContext 发送邮件，ctx 取消或到达截止时间时中断 SMTP 会话
+func (c *Client) SendContext(ctx context.Context, msg *Message) error {
 	if len(msg.To) == 0 {
 		return fmt.Errorf("no recipients specified")
 	}
 
-	// 构建邮件内容
-	content := c.buildMessage(msg)
-
-	// SMTP认证
-	auth := smtp.PlainAuth("", c.username, c.password, c.smtpHost)
+	env, err := c.newEnvelope(msg)
+	if err != nil {
+		return err
+	}
 
-	// 发送邮件
-	addr := fmt.Sprintf("%s:%d", c.smtpHost, c.smtpPort)
-	err := smtp.SendMail(addr, auth, c.from, msg.To, []byte(content))
+	// 构建邮件内容
+	content, err := c.buildMessage(msg, env, time.Now())
 	if err != nil {
+		return err
+	}
+
+	// 发送邮件，Bcc 只出现在 SMTP 收件人中
+	if err := c.deliver(ctx, env.from.Address, env.recipients(), content); err != nil {
+		// 连接因 ctx 取消被关闭时，报告取消原因而不是连接错误
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("failed to send email: %w (%v)", ctxErr, err)
+		}
 		return fmt.Errorf("failed to send email: %w", err)
 	}
 
@@ -57,28 +90,89 @@ func (c *Client) Send(msg *Message) error {
 }
 
 // buildMessage 构建邮件内容
-func (c *Client) buildMessage(msg *Message) string {
-	var sb strings.Builder
+// 正文使用 quoted-printable 编码；HTML 邮件带有纯文本版本时生成 multipart/alternative，纯文本在前
+func (c *Client) buildMessage(msg *Message, env *envelope, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
 
-	// 邮件头
-	sb.WriteString(fmt.Sprintf("From: %s\r\n", c.from))
-	sb.WriteString(fmt.Sprintf("To: %s\r\n", strings.Join(msg.To, ", ")))
-	sb.WriteString(fmt.Sprintf("Subject: %s\r\n", msg.Subject))
-	sb.WriteString("MIME-Version: 1.0\r\n")
+	messageID, err := newMessageID(env.from, now)
+	if err != nil {
+		return nil, err
+	}
 
-	// 内容类型
-	if msg.IsHTML {
-		sb.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
-	} else {
-		sb.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	// 邮件头，显示名称和主题按 RFC 2047 编码
+	buf.WriteString(fmt.Sprintf("From: %s\r\n", env.from.String()))
+	buf.WriteString(fmt.Sprintf("To: %s\r\n", formatAddressList(env.to)))
+	if len(env.cc) > 0 {
+		buf.WriteString(fmt.Sprintf("Cc: %s\r\n", formatAddressList(env.cc)))
+	}
+	if len(env.replyTo) > 0 {
+		buf.WriteString(fmt.Sprintf("Reply-To: %s\r\n", formatAddressList(env.replyTo)))
+	}
+	buf.WriteString(fmt.Sprintf("Subject: %s\r\n", encodeHeader(msg.Subject)))
+	buf.WriteString(fmt.Sprintf("Date: %s\r\n", now.Format(time.RFC1123Z)))
+	buf.WriteString(fmt.Sprintf("Message-ID: %s\r\n", messageID))
+	if unsubscribe := formatListUnsubscribe(msg.ListUnsubscribe); unsubscribe != "" {
+		buf.WriteString(fmt.Sprintf("List-Unsubscribe: %s\r\n", unsubscribe))
+	}
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	// 只有一种内容类型时直接写入正文
+	if !msg.IsHTML || msg.TextBody == "" {
+		contentType := "text/plain; charset=UTF-8"
+		if msg.IsHTML {
+			contentType = "text/html; charset=UTF-8"
+		}
+		buf.WriteString(fmt.Sprintf("Content-Type: %s\r\n", contentType))
+		buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, msg.Body); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
 	}
 
-	sb.WriteString("\r\n")
+	// 纯文本和 HTML 两个版本，邮件客户端选择最后一个能显示的版本
+	mw := multipart.NewWriter(&buf)
+	contentType := mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": mw.Boundary()})
+	buf.WriteString(fmt.Sprintf("Content-Type: %s\r\n", contentType))
+	buf.WriteString("\r\n")
+
+	parts := []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=UTF-8", msg.TextBody},
+		{"text/html; charset=UTF-8", msg.Body},
+	}
+	for _, part := range parts {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create message part: %w", err)
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to build message: %w", err)
+	}
 
-	// 邮件正文
-	sb.WriteString(msg.Body)
+	return buf.Bytes(), nil
+}
 
-	return sb.String()
+// writeQuotedPrintable 以 quoted-printable 编码写入正文，换行统一为 CRLF
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := io.WriteString(qp, body); err != nil {
+		return fmt.Errorf("failed to encode message body: %w", err)
+	}
+	if err := qp.Close(); err != nil {
+		return fmt.Errorf("failed to encode message body: %w", err)
+	}
+	return nil
 }
 
 // SendText 发送纯文本邮件
@@ -103,6 +197,18 @@ func (c *Client) SendHTML(to []string, subject, body string) error {
 	return c.Send(msg)
 }
 
+// SendAlternative 发送同时包含 HTML 和纯文本版本的邮件
+func (c *Client) SendAlternative(to []string, subject, htmlBody, textBody string) error {
+	msg := &Message{
+		To:       to,
+		Subject:  subject,
+		Body:     htmlBody,
+		IsHTML:   true,
+		TextBody: textBody,
+	}
+	return c.Send(msg)
+}
+
 // ValidateConfig 验证邮件配置
 func ValidateConfig(smtpHost, username, password, from string, to []string) error {
 	if smtpHost == "" {
@@ -0,0 +1,124 @@
+package email
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildMessageBody(t *testing.T) {
+	// 超过 76 个字符的行和非 ASCII 字符都需要 quoted-printable 编码
+	text := "今日 GitHub 热门仓库\n" + strings.Repeat("a=b ", 30) + "\n"
+	html := "<h1>今日 GitHub 热门仓库</h1>\n<p>" + strings.Repeat("a=b ", 30) + "</p>\n"
+
+	type part struct {
+		contentType string
+		body        string
+	}
+	tests := []struct {
+		name  string
+		msg   *Message
+		parts []part // 只有一项时为单一正文
+	}{
+		{"plain text", &Message{Body: text},
+			[]part{{"text/plain", text}}},
+		{"html only", &Message{Body: html, IsHTML: true},
+			[]part{{"text/html", html}}},
+		{"text alternative ignored for plain text", &Message{Body: text, TextBody: "unused"},
+			[]part{{"text/plain", text}}},
+		{"multipart alternative", &Message{Body: html, IsHTML: true, TextBody: text},
+			[]part{{"text/plain", text}, {"text/html", html}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.msg.To = []string{"to@example.com"}
+			tt.msg.Subject = "Trending"
+			parsed, content := buildTestMessage(t, "bot@example.com", tt.msg, time.Now())
+
+			for _, line := range strings.SplitAfter(string(content), "\n") {
+				if !strings.HasSuffix(line, "\r\n") && line != "" {
+					t.Errorf("line not terminated by CRLF: %q", line)
+				}
+				if len(strings.TrimSuffix(line, "\r\n")) > 998 {
+					t.Errorf("line longer than 998 characters: %q", line)
+				}
+			}
+
+			if parsed.Header.Get("MIME-Version") != "1.0" {
+				t.Errorf("MIME-Version = %q", parsed.Header.Get("MIME-Version"))
+			}
+			mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(tt.parts) == 1 {
+				want := tt.parts[0]
+				if mediaType != want.contentType || params["charset"] != "UTF-8" {
+					t.Errorf("Content-Type = %q, want %s; charset=UTF-8", parsed.Header.Get("Content-Type"), want.contentType)
+				}
+				checkQuotedPrintable(t, parsed.Header.Get("Content-Transfer-Encoding"), parsed.Body, want.body)
+				return
+			}
+
+			if mediaType != "multipart/alternative" || params["boundary"] == "" {
+				t.Fatalf("Content-Type = %q, want multipart/alternative with a boundary", parsed.Header.Get("Content-Type"))
+			}
+			if strings.Contains(text+html, params["boundary"]) {
+				t.Errorf("boundary %q appears in the body", params["boundary"])
+			}
+
+			mr := multipart.NewReader(parsed.Body, params["boundary"])
+			for i, want := range tt.parts {
+				// NextRawPart 不解码 quoted-printable，以便检查 Content-Transfer-Encoding
+				p, err := mr.NextRawPart()
+				if err != nil {
+					t.Fatalf("part %d: %v", i, err)
+				}
+				partType, partParams, err := mime.ParseMediaType(p.Header.Get("Content-Type"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if partType != want.contentType || partParams["charset"] != "UTF-8" {
+					t.Errorf("part %d Content-Type = %q, want %s; charset=UTF-8", i, p.Header.Get("Content-Type"), want.contentType)
+				}
+				checkQuotedPrintable(t, p.Header.Get("Content-Transfer-Encoding"), p, want.body)
+			}
+			if _, err := mr.NextRawPart(); err != io.EOF {
+				t.Errorf("expected %d parts, got more (err = %v)", len(tt.parts), err)
+			}
+		})
+	}
+}
+
+// checkQuotedPrintable 检查正文使用 quoted-printable 编码，解码后换行为 CRLF，内容与 want 一致
+func checkQuotedPrintable(t *testing.T, encoding string, r io.Reader, want string) {
+	t.Helper()
+	if encoding != "quoted-printable" {
+		t.Errorf("Content-Transfer-Encoding = %q, want quoted-printable", encoding)
+	}
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isASCII(string(raw)) {
+		t.Errorf("encoded body is not ASCII: %q", raw)
+	}
+	for _, line := range strings.Split(string(raw), "\r\n") {
+		if len(line) > 76 {
+			t.Errorf("encoded line longer than 76 characters: %q", line)
+		}
+	}
+	decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(string(raw))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.ReplaceAll(string(decoded), "\r\n", "\n"); got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
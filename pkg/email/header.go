@@ -0,0 +1,142 @@
+package email
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// envelope 解析后的发件人和收件人
+type envelope struct {
+	from    *mail.Address
+	to      []*mail.Address
+	cc      []*mail.Address
+	bcc     []*mail.Address
+	replyTo []*mail.Address
+}
+
+// newEnvelope 解析发件人和所有收件人地址，地址可以带显示名称，如 "张三 <zhangsan@example.com>"
+func (c *Client) newEnvelope(msg *Message) (*envelope, error) {
+	from, err := mail.ParseAddress(c.from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address %q: %w", c.from, err)
+	}
+
+	env := &envelope{from: from}
+	lists := []struct {
+		header string
+		list   []string
+		dst    *[]*mail.Address
+	}{
+		{"To", msg.To, &env.to},
+		{"Cc", msg.Cc, &env.cc},
+		{"Bcc", msg.Bcc, &env.bcc},
+		{"Reply-To", msg.ReplyTo, &env.replyTo},
+	}
+	for _, l := range lists {
+		for _, s := range l.list {
+			addr, err := mail.ParseAddress(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s address %q: %w", l.header, s, err)
+			}
+			*l.dst = append(*l.dst, addr)
+		}
+	}
+	return env, nil
+}
+
+// recipients SMTP 投递的收件人地址，包括 Bcc，重复的地址只投递一次
+func (e *envelope) recipients() []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, list := range [][]*mail.Address{e.to, e.cc, e.bcc} {
+		for _, addr := range list {
+			key := strings.ToLower(addr.Address)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			result = append(result, addr.Address)
+		}
+	}
+	return result
+}
+
+// formatAddressList 格式化地址列表，非 ASCII 的显示名称按 RFC 2047 编码
+func formatAddressList(addrs []*mail.Address) string {
+	parts := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		parts = append(parts, addr.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// maxHeaderLine 邮件头折行的长度，与 encoded-word 的长度上限一致
+const maxHeaderLine = 75
+
+// encodeHeader 按 RFC 2047 编码包含非 ASCII 字符的邮件头，过长时折行
+// 中文等字符使用 B 编码，比 Q 编码更短
+func encodeHeader(s string) string {
+	s = sanitizeHeader(s)
+	encoded := mime.BEncoding.Encode("UTF-8", s)
+	if encoded == s {
+		return foldHeader(s)
+	}
+	// 多个 encoded-word 之间以空格分隔，在空格处折行，避免超过邮件头的行长度限制
+	return strings.ReplaceAll(encoded, "?= =?", "?=\r\n =?")
+}
+
+// foldHeader 在空格处折行，每行不超过 maxHeaderLine 个字符，没有空格的长单词不拆分
+func foldHeader(s string) string {
+	var b strings.Builder
+	lineLen := 0
+	for i, word := range strings.Split(s, " ") {
+		if i > 0 {
+			// 折行后的行以空格开头，只有空格的行不再折行
+			if lineLen > 1 && lineLen+1+len(word) > maxHeaderLine {
+				b.WriteString("\r\n")
+				lineLen = 0
+			}
+			b.WriteByte(' ')
+			lineLen++
+		}
+		b.WriteString(word)
+		lineLen += len(word)
+	}
+	return b.String()
+}
+
+// sanitizeHeader 去掉换行，防止邮件头注入
+func sanitizeHeader(s string) string {
+	return strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(s)
+}
+
+// formatListUnsubscribe 格式化 List-Unsubscribe 邮件头，如 "<mailto:unsubscribe@example.com>, <https://example.com/unsubscribe>"
+func formatListUnsubscribe(uris []string) string {
+	parts := make([]string, 0, len(uris))
+	for _, uri := range uris {
+		uri = strings.Trim(strings.TrimSpace(sanitizeHeader(uri)), "<>")
+		if uri != "" {
+			parts = append(parts, "<"+uri+">")
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// newMessageID 生成唯一的 Message-ID，域名取自发件人地址
+func newMessageID(from *mail.Address, now time.Time) (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate Message-ID: %w", err)
+	}
+
+	domain := "localhost"
+	if idx := strings.LastIndex(from.Address, "@"); idx >= 0 && idx < len(from.Address)-1 {
+		domain = from.Address[idx+1:]
+	}
+	return fmt.Sprintf("<%d.%s@%s>", now.UnixNano(), hex.EncodeToString(b), domain), nil
+}
@@ -0,0 +1,176 @@
+package email
+
+import (
+	"bytes"
+	"mime"
+	"net/mail"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// buildTestMessage 以 from 为发件人构建邮件，并按 RFC 5322 解析
+func buildTestMessage(t *testing.T, from string, msg *Message, now time.Time) (*mail.Message, []byte) {
+	t.Helper()
+	c := NewClient("smtp.example.com", 587, "", "", from)
+	env, err := c.newEnvelope(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := c.buildMessage(msg, env, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := mail.ReadMessage(bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("invalid message: %v\n%s", err, content)
+	}
+	return parsed, content
+}
+
+// decodeHeader 解码 RFC 2047 编码的邮件头
+func decodeHeader(t *testing.T, s string) string {
+	t.Helper()
+	decoded, err := new(mime.WordDecoder).DecodeHeader(s)
+	if err != nil {
+		t.Fatalf("failed to decode header %q: %v", s, err)
+	}
+	return decoded
+}
+
+func TestEncodeHeader(t *testing.T) {
+	longChinese := strings.Repeat("今日 GitHub 热门仓库，", 8)
+	longASCII := strings.Repeat("GitHub trending repositories for today ", 5)
+
+	tests := []struct {
+		name    string
+		in      string
+		want    string // 解码并展开折行后的内容
+		encoded bool   // 是否应按 RFC 2047 编码
+	}{
+		{"ascii", "GitHub Trending", "GitHub Trending", false},
+		{"chinese", "今日 GitHub 热门", "今日 GitHub 热门", true},
+		{"long chinese", longChinese, longChinese, true},
+		{"long ascii", longASCII, longASCII, false},
+		{"crlf injection", "Hi\r\nBcc: evil@example.com", "Hi Bcc: evil@example.com", false},
+		{"bare lf", "第一行\n第二行", "第一行 第二行", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := encodeHeader(tt.in)
+
+			lines := strings.Split(got, "\r\n")
+			for i, line := range lines {
+				if strings.ContainsAny(line, "\r\n") {
+					t.Errorf("line %d contains a bare CR or LF: %q", i, line)
+				}
+				if i > 0 && !strings.HasPrefix(line, " ") {
+					t.Errorf("folded line %d does not start with whitespace: %q", i, line)
+				}
+				if strings.TrimSpace(line) == "" {
+					t.Errorf("line %d is empty: %q", i, got)
+				}
+				if len(line) > maxHeaderLine+1 {
+					t.Errorf("line %d is %d characters long: %q", i, len(line), line)
+				}
+			}
+			if (len(tt.in) > 2*maxHeaderLine) && len(lines) < 2 {
+				t.Errorf("long header was not folded: %q", got)
+			}
+
+			if encoded := strings.Contains(got, "=?UTF-8?b?"); encoded != tt.encoded {
+				t.Errorf("encoded = %v, want %v: %q", encoded, tt.encoded, got)
+			}
+
+			// 接收方展开折行：去掉 CRLF，保留后面的空格
+			unfolded := strings.ReplaceAll(got, "\r\n", "")
+			if decoded := decodeHeader(t, unfolded); decoded != tt.want {
+				t.Errorf("decoded = %q, want %q", decoded, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildMessageHeaders(t *testing.T) {
+	now := time.Date(2026, 5, 5, 7, 30, 0, 0, time.FixedZone("CST", 8*3600))
+	msg := &Message{
+		To:              []string{"张三 <zhangsan@example.com>", "bob@example.com"},
+		Cc:              []string{"李四 <lisi@example.com>"},
+		Bcc:             []string{"hidden@example.com"},
+		ReplyTo:         []string{"Team <team@example.com>"},
+		Subject:         "今日热门\r\nBcc: evil@example.com",
+		Body:            "report",
+		ListUnsubscribe: []string{"mailto:unsubscribe@example.com", "<https://example.com/unsubscribe>", "\r\n"},
+	}
+	parsed, content := buildTestMessage(t, "趋势机器人 <bot@example.com>", msg, now)
+
+	addresses := []struct {
+		header string
+		want   []string
+	}{
+		{"From", []string{"趋势机器人 <bot@example.com>"}},
+		{"To", []string{"张三 <zhangsan@example.com>", "<bob@example.com>"}},
+		{"Cc", []string{"李四 <lisi@example.com>"}},
+		{"Reply-To", []string{"Team <team@example.com>"}},
+	}
+	for _, a := range addresses {
+		list, err := parsed.Header.AddressList(a.header)
+		if err != nil {
+			t.Errorf("%s: %v", a.header, err)
+			continue
+		}
+		var got []string
+		for _, addr := range list {
+			got = append(got, strings.TrimSpace(addr.Name+" <"+addr.Address+">"))
+		}
+		if strings.Join(got, ", ") != strings.Join(a.want, ", ") {
+			t.Errorf("%s = %v, want %v", a.header, got, a.want)
+		}
+	}
+
+	// 非 ASCII 的显示名称必须编码，不能以 UTF-8 原样写入
+	for _, header := range []string{"From", "To", "Cc"} {
+		if v := parsed.Header.Get(header); !isASCII(v) {
+			t.Errorf("%s header is not ASCII: %q", header, v)
+		}
+	}
+
+	if got := decodeHeader(t, parsed.Header.Get("Subject")); got != "今日热门 Bcc: evil@example.com" {
+		t.Errorf("Subject = %q", got)
+	}
+	if _, ok := parsed.Header["Bcc"]; ok {
+		t.Errorf("Bcc header must not be written: %s", content)
+	}
+
+	date, err := parsed.Header.Date()
+	if err != nil {
+		t.Errorf("Date: %v", err)
+	} else if !date.Equal(now) {
+		t.Errorf("Date = %v, want %v", date, now)
+	}
+
+	messageID := parsed.Header.Get("Message-ID")
+	if !regexp.MustCompile(`^<\d+\.[0-9a-f]{24}@example\.com>$`).MatchString(messageID) {
+		t.Errorf("Message-ID = %q", messageID)
+	}
+	if _, other := buildTestMessage(t, "bot@example.com", msg, now); bytes.Contains(other, []byte(messageID)) {
+		t.Errorf("Message-ID %s was reused", messageID)
+	}
+
+	want := "<mailto:unsubscribe@example.com>, <https://example.com/unsubscribe>"
+	if got := parsed.Header.Get("List-Unsubscribe"); got != want {
+		t.Errorf("List-Unsubscribe = %q, want %q", got, want)
+	}
+}
+
+// isASCII 字符串是否只包含 ASCII 字符
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,242 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TLS 模式
+const (
+	TLSAuto             = ""                  // 465 端口使用 implicit，其他端口使用 starttls
+	TLSNone             = "none"              // 明文连接，不使用 TLS
+	TLSStartTLS         = "starttls"          // 服务器支持时使用 STARTTLS
+	TLSStartTLSRequired = "starttls-required" // 必须使用 STARTTLS，服务器不支持时发送失败
+	TLSImplicit         = "implicit"          // 连接建立后直接进行 TLS 握手，如 465 端口
+)
+
+// 认证方式
+const (
+	AuthPlain   = "plain"    // AUTH PLAIN（默认）
+	AuthLogin   = "login"    // AUTH LOGIN，部分 Exchange/国内邮箱只支持这种方式
+	AuthCRAMMD5 = "cram-md5" // AUTH CRAM-MD5，密码不以明文传输
+)
+
+// 默认的超时时间，包括连接和整个 SMTP 会话
+const defaultTimeout = 30 * time.Second
+
+// Options 邮件客户端的连接参数
+type Options struct {
+	TLSMode    string        // TLS 模式，默认 TLSAuto
+	CAFile     string        // 自定义 CA 证书文件（PEM），为空时使用系统证书
+	ServerName string        // 校验证书使用的服务器名称，为空时使用 SMTP 主机名
+	Auth       string        // 认证方式，默认 AuthPlain
+	Timeout    time.Duration // 连接和发送一封邮件的超时时间，默认30秒
+}
+
+// NewClientWithOptions 使用指定的 TLS 模式和认证方式创建邮件客户端
+func NewClientWithOptions(smtpHost string, smtpPort int, username, password, from string, opts Options) (*Client, error) {
+	c := NewClient(smtpHost, smtpPort, username, password, from)
+
+	c.tlsMode = strings.ToLower(opts.TLSMode)
+	if c.tlsMode == TLSAuto {
+		c.tlsMode = TLSStartTLS
+		if smtpPort == 465 {
+			c.tlsMode = TLSImplicit
+		}
+	}
+	switch c.tlsMode {
+	case TLSNone, TLSStartTLS, TLSStartTLSRequired, TLSImplicit:
+	default:
+		return nil, fmt.Errorf("unknown TLS mode: %s", opts.TLSMode)
+	}
+
+	c.auth = strings.ToLower(opts.Auth)
+	if c.auth == "" {
+		c.auth = AuthPlain
+	}
+	switch c.auth {
+	case AuthPlain, AuthLogin, AuthCRAMMD5:
+	default:
+		return nil, fmt.Errorf("unknown auth method: %s", opts.Auth)
+	}
+
+	c.tlsConfig = &tls.Config{
+		ServerName: smtpHost,
+		MinVersion: tls.VersionTLS12,
+	}
+	if opts.ServerName != "" {
+		c.tlsConfig.ServerName = opts.ServerName
+	}
+	if opts.CAFile != "" {
+		pool, err := loadCertPool(opts.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		c.tlsConfig.RootCAs = pool
+	}
+	if opts.Timeout > 0 {
+		c.timeout = opts.Timeout
+	}
+
+	return c, nil
+}
+
+// loadCertPool 加载系统证书和自定义 CA 证书
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in CA file %s", path)
+	}
+	return pool, nil
+}
+
+// deliver 按 TLS 模式连接 SMTP 服务器，认证后投递邮件
+// 整个会话在超时时间或 ctx 的截止时间（取较早者）之前完成，ctx 取消时关闭连接
+func (c *Client) deliver(ctx context.Context, from string, recipients []string, content []byte) error {
+	addr := net.JoinHostPort(c.smtpHost, strconv.Itoa(c.smtpPort))
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	dialer := &net.Dialer{Deadline: deadline}
+
+	var conn net.Conn
+	var err error
+	if c.tlsMode == TLSImplicit {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: c.tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server %s: %w", addr, err)
+	}
+
+	// 服务器不响应时读写在截止时间返回，ctx 取消时关闭连接中断正在进行的读写
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to set SMTP deadline: %w", err)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, c.smtpHost)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	// STARTTLS
+	if c.tlsMode == TLSStartTLS || c.tlsMode == TLSStartTLSRequired {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(c.tlsConfig); err != nil {
+				return fmt.Errorf("STARTTLS failed: %w", err)
+			}
+		} else if c.tlsMode == TLSStartTLSRequired {
+			return fmt.Errorf("SMTP server %s does not support STARTTLS", addr)
+		}
+	}
+
+	// 认证
+	if c.username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return fmt.Errorf("SMTP server %s does not support AUTH", addr)
+		}
+		if err := client.Auth(c.smtpAuth()); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	// 投递
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("MAIL FROM failed: %w", err)
+	}
+	for _, rcpt := range recipients {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("RCPT TO %s failed: %w", rcpt, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("DATA failed: %w", err)
+	}
+	if _, err := w.Write(content); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	return client.Quit()
+}
+
+// smtpAuth 按认证方式创建 smtp.Auth
+func (c *Client) smtpAuth() smtp.Auth {
+	switch c.auth {
+	case AuthLogin:
+		return &loginAuth{username: c.username, password: c.password, host: c.smtpHost}
+	case AuthCRAMMD5:
+		return smtp.CRAMMD5Auth(c.username, c.password)
+	default:
+		return smtp.PlainAuth("", c.username, c.password, c.smtpHost)
+	}
+}
+
+// loginAuth AUTH LOGIN 认证，依次发送用户名和密码
+// 与 smtp.PlainAuth 一样，只在 TLS 连接或本机服务器上发送密码
+type loginAuth struct {
+	username string
+	password string
+	host     string
+	step     int
+}
+
+// Start 开始认证
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	a.step = 0
+	return "LOGIN", nil, nil
+}
+
+// Next 回应服务器的 "Username:" 和 "Password:" 提示
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	a.step++
+	switch a.step {
+	case 1:
+		return []byte(a.username), nil
+	case 2:
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected LOGIN challenge: %q", fromServer)
+	}
+}
+
+// isLocalhost 是否为本机地址
+func isLocalhost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
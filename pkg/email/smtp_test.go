@@ -0,0 +1,431 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testUser     = "bot@example.com"
+	testPassword = "secret"
+)
+
+// smtpSession 测试服务器记录的会话
+type smtpSession struct {
+	tls   bool     // 投递时连接是否已加密
+	auth  string   // 认证成功的方式
+	from  string   // MAIL FROM
+	rcpts []string // RCPT TO
+	data  string
+}
+
+// fakeSMTP 只实现发送邮件所需命令的 SMTP 服务器
+type fakeSMTP struct {
+	addr      string
+	tlsConfig *tls.Config // 不为空时在 EHLO 中提供 STARTTLS
+	implicit  bool        // 连接建立后直接进行 TLS 握手
+	silent    bool        // 接受连接后不发送问候，模拟无响应的服务器
+
+	mu       sync.Mutex
+	sessions []*smtpSession
+}
+
+// newFakeSMTP 启动测试服务器，setup 在开始接受连接前修改服务器设置
+func newFakeSMTP(t *testing.T, setup func(s *fakeSMTP)) *fakeSMTP {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	s := &fakeSMTP{addr: ln.Addr().String()}
+	if setup != nil {
+		setup(s)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+// client 创建连接测试服务器的邮件客户端
+func (s *fakeSMTP) client(t *testing.T, opts Options) *Client {
+	t.Helper()
+	host, port, err := net.SplitHostPort(s.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewClientWithOptions(host, p, testUser, testPassword, "Trending Bot <"+testUser+">", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// delivered 投递成功的会话
+func (s *fakeSMTP) delivered() []*smtpSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*smtpSession(nil), s.sessions...)
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if s.silent {
+		// 读到客户端关闭连接为止
+		bufio.NewReader(conn).ReadString('\n')
+		return
+	}
+
+	session := &smtpSession{}
+	if s.implicit {
+		tlsConn := tls.Server(conn, s.tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			return
+		}
+		conn = tlsConn
+		session.tls = true
+	}
+
+	r := bufio.NewReader(conn)
+	reply := func(lines ...string) {
+		fmt.Fprint(conn, strings.Join(lines, "\r\n")+"\r\n")
+	}
+	readLine := func() (string, bool) {
+		line, err := r.ReadString('\n')
+		return strings.TrimRight(line, "\r\n"), err == nil
+	}
+
+	reply("220 localhost ESMTP test")
+	for {
+		line, ok := readLine()
+		if !ok {
+			return
+		}
+		cmd, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(cmd) {
+		case "EHLO":
+			lines := []string{"250-localhost"}
+			if s.tlsConfig != nil && !session.tls {
+				lines = append(lines, "250-STARTTLS")
+			}
+			reply(append(lines, "250 AUTH PLAIN LOGIN CRAM-MD5")...)
+		case "STARTTLS":
+			if s.tlsConfig == nil || session.tls {
+				reply("502 not supported")
+				continue
+			}
+			reply("220 ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			r = bufio.NewReader(conn)
+			session.tls = true
+		case "AUTH":
+			if s.authenticate(arg, reply, readLine) {
+				session.auth = strings.ToLower(strings.Fields(arg)[0])
+				reply("235 authenticated")
+			} else {
+				reply("535 authentication failed")
+			}
+		case "MAIL":
+			session.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			reply("250 ok")
+		case "RCPT":
+			session.rcpts = append(session.rcpts, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				line, ok := readLine()
+				if !ok {
+					return
+				}
+				if line == "." {
+					break
+				}
+				data.WriteString(line + "\r\n")
+			}
+			session.data = data.String()
+			s.mu.Lock()
+			s.sessions = append(s.sessions, session)
+			s.mu.Unlock()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 unknown command")
+		}
+	}
+}
+
+// authenticate 按 AUTH 命令的方式校验用户名和密码
+func (s *fakeSMTP) authenticate(arg string, reply func(...string), readLine func() (string, bool)) bool {
+	fields := strings.Fields(arg)
+	decode := func(s string) string {
+		b, _ := base64.StdEncoding.DecodeString(s)
+		return string(b)
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "PLAIN":
+		if len(fields) < 2 {
+			return false
+		}
+		return decode(fields[1]) == "\x00"+testUser+"\x00"+testPassword
+	case "LOGIN":
+		reply("334 " + base64.StdEncoding.EncodeToString([]byte("Username:")))
+		user, _ := readLine()
+		reply("334 " + base64.StdEncoding.EncodeToString([]byte("Password:")))
+		password, _ := readLine()
+		return decode(user) == testUser && decode(password) == testPassword
+	case "CRAM-MD5":
+		challenge := "<1896.697170952@localhost>"
+		reply("334 " + base64.StdEncoding.EncodeToString([]byte(challenge)))
+		line, _ := readLine()
+		user, digest, _ := strings.Cut(decode(line), " ")
+		mac := hmac.New(md5.New, []byte(testPassword))
+		mac.Write([]byte(challenge))
+		return user == testUser && digest == hex.EncodeToString(mac.Sum(nil))
+	default:
+		return false
+	}
+}
+
+// newTestCertificate 生成 127.0.0.1 的自签名证书，返回服务器 TLS 配置和 PEM 格式的 CA 文件路径
+func newTestCertificate(t *testing.T) (*tls.Config, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:              []string{"localhost"},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cert := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	return &tls.Config{Certificates: []tls.Certificate{cert}}, caFile
+}
+
+// testMessage 测试邮件，Bcc 只出现在 RCPT TO 中
+func testMessage() *Message {
+	return &Message{
+		To:      []string{"张三 <zhangsan@example.com>"},
+		Bcc:     []string{"archive@example.com"},
+		Subject: "GitHub Trending",
+		Body:    "report",
+	}
+}
+
+func TestSendTLSModes(t *testing.T) {
+	serverTLS, caFile := newTestCertificate(t)
+
+	tests := []struct {
+		name     string
+		starttls bool // 服务器提供 STARTTLS
+		implicit bool // 服务器使用 implicit TLS
+		opts     Options
+		wantTLS  bool
+		wantErr  string // 为空表示发送成功
+	}{
+		{"none", true, false, Options{TLSMode: TLSNone}, false, ""},
+		{"starttls offered", true, false, Options{TLSMode: TLSStartTLS, CAFile: caFile}, true, ""},
+		{"starttls not offered", false, false, Options{TLSMode: TLSStartTLS}, false, ""},
+		{"starttls-required offered", true, false, Options{TLSMode: TLSStartTLSRequired, CAFile: caFile}, true, ""},
+		{"starttls-required not offered", false, false, Options{TLSMode: TLSStartTLSRequired}, false, "does not support STARTTLS"},
+		{"starttls untrusted certificate", true, false, Options{TLSMode: TLSStartTLSRequired}, false, "STARTTLS failed"},
+		{"starttls wrong server name", true, false, Options{TLSMode: TLSStartTLS, CAFile: caFile, ServerName: "mail.example.com"}, false, "STARTTLS failed"},
+		{"implicit", false, true, Options{TLSMode: TLSImplicit, CAFile: caFile}, true, ""},
+		{"implicit untrusted certificate", false, true, Options{TLSMode: TLSImplicit}, false, "failed to connect"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSMTP(t, func(s *fakeSMTP) {
+				if tt.starttls || tt.implicit {
+					s.tlsConfig = serverTLS
+				}
+				s.implicit = tt.implicit
+			})
+			err := server.client(t, tt.opts).Send(testMessage())
+
+			sessions := server.delivered()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if len(sessions) != 0 {
+					t.Errorf("message was delivered despite the error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(sessions) != 1 {
+				t.Fatalf("delivered %d messages, want 1", len(sessions))
+			}
+			got := sessions[0]
+			if got.tls != tt.wantTLS {
+				t.Errorf("tls = %v, want %v", got.tls, tt.wantTLS)
+			}
+			if got.from != testUser {
+				t.Errorf("MAIL FROM = %q, want %q", got.from, testUser)
+			}
+			if strings.Join(got.rcpts, ",") != "zhangsan@example.com,archive@example.com" {
+				t.Errorf("RCPT TO = %v", got.rcpts)
+			}
+			if !strings.Contains(got.data, "Subject: GitHub Trending\r\n") || strings.Contains(got.data, "archive@example.com") {
+				t.Errorf("unexpected message:\n%s", got.data)
+			}
+		})
+	}
+}
+
+func TestSendAuth(t *testing.T) {
+	tests := []struct {
+		auth     string
+		password string
+		wantErr  bool
+	}{
+		{AuthPlain, testPassword, false},
+		{AuthLogin, testPassword, false},
+		{AuthCRAMMD5, testPassword, false},
+		{AuthPlain, "wrong", true},
+		{AuthLogin, "wrong", true},
+		{AuthCRAMMD5, "wrong", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.auth+"/"+tt.password, func(t *testing.T) {
+			server := newFakeSMTP(t, nil)
+			c := server.client(t, Options{TLSMode: TLSNone, Auth: tt.auth})
+			c.password = tt.password
+
+			err := c.Send(testMessage())
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "authentication failed") {
+					t.Errorf("err = %v, want an authentication failure", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sessions := server.delivered(); len(sessions) != 1 || sessions[0].auth != tt.auth {
+				t.Errorf("sessions = %+v, want one authenticated with %s", sessions, tt.auth)
+			}
+		})
+	}
+}
+
+func TestLoginAuthRequiresTLS(t *testing.T) {
+	a := &loginAuth{username: testUser, password: testPassword, host: "smtp.example.com"}
+	if _, _, err := a.Start(&smtp.ServerInfo{Name: "smtp.example.com"}); err == nil {
+		t.Error("LOGIN must not send the password over an unencrypted connection to a remote server")
+	}
+	if _, _, err := a.Start(&smtp.ServerInfo{Name: "smtp.example.com", TLS: true}); err != nil {
+		t.Errorf("LOGIN over TLS: %v", err)
+	}
+	if _, _, err := a.Start(&smtp.ServerInfo{Name: "other.example.com", TLS: true}); err == nil {
+		t.Error("LOGIN must reject a different host name")
+	}
+}
+
+func TestSendDeadline(t *testing.T) {
+	t.Run("timeout", func(t *testing.T) {
+		server := newFakeSMTP(t, func(s *fakeSMTP) { s.silent = true })
+		c := server.client(t, Options{TLSMode: TLSNone, Timeout: 100 * time.Millisecond})
+
+		start := time.Now()
+		err := c.Send(testMessage())
+		var netErr net.Error
+		if !errors.As(err, &netErr) || !netErr.Timeout() {
+			t.Errorf("err = %v, want a timeout", err)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("Send returned after %v", elapsed)
+		}
+	})
+
+	t.Run("context deadline", func(t *testing.T) {
+		server := newFakeSMTP(t, func(s *fakeSMTP) { s.silent = true })
+		c := server.client(t, Options{TLSMode: TLSNone, Timeout: time.Minute})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		err := c.SendContext(ctx, testMessage())
+		if err == nil {
+			t.Fatal("expected an error from an unresponsive server")
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("SendContext returned after %v", elapsed)
+		}
+	})
+
+	t.Run("context canceled", func(t *testing.T) {
+		server := newFakeSMTP(t, func(s *fakeSMTP) { s.silent = true })
+		c := server.client(t, Options{TLSMode: TLSNone, Timeout: time.Minute})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+		err := c.SendContext(ctx, testMessage())
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("err = %v, want context.Canceled", err)
+		}
+	})
+}
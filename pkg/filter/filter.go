@@ -0,0 +1,230 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/github-insight-analyze/trending-notifier/pkg/api"
+)
+
+// 规则动作
+const (
+	ActionInclude = "include" // 只保留匹配规则的仓库
+	ActionExclude = "exclude" // 去掉匹配规则的仓库
+)
+
+// Rule 过滤规则
+// 规则中设置的所有条件同时满足才算匹配，未设置的条件不参与判断
+type Rule struct {
+	Name   string // 规则名称，用于日志和 dry-run 输出
+	Action string // ActionInclude 或 ActionExclude
+	Reason string // 仓库被该规则过滤时显示的原因，为空时自动生成
+
+	Owners              []string // 仓库所有者，不区分大小写，满足其一即可
+	NameRegex           string   // 匹配仓库全名（owner/name）的正则表达式
+	DescriptionKeywords []string // 描述中包含的关键字，不区分大小写，满足其一即可
+	Languages           []string // 编程语言，不区分大小写，满足其一即可
+	Topics              []string // GitHub topic，不区分大小写，满足其一即可
+	MinStars            int      // star 数不少于该值
+	MinForks            int      // fork 数不少于该值
+	MinPushes           int      // push 数不少于该值
+	MinPullRequests     int      // PR 数不少于该值
+	Archived            bool     // 仓库已归档
+	EmptyDescription    bool     // 仓库没有描述
+}
+
+// HasConditions 规则是否至少设置了一个条件
+func (r *Rule) HasConditions() bool {
+	return len(r.Owners) > 0 || r.NameRegex != "" || len(r.DescriptionKeywords) > 0 ||
+		len(r.Languages) > 0 || len(r.Topics) > 0 || r.MinStars > 0 || r.MinForks > 0 ||
+		r.MinPushes > 0 || r.MinPullRequests > 0 || r.Archived || r.EmptyDescription
+}
+
+// Dropped 被过滤掉的仓库及原因
+type Dropped struct {
+	Repo   api.Repository
+	Rule   string
+	Reason string
+}
+
+// Pipeline 按顺序执行的过滤规则
+// 仓库依次经过每条规则，exclude 规则去掉匹配的仓库，include 规则去掉不匹配的仓库，
+// 第一条过滤掉仓库的规则作为过滤原因
+type Pipeline struct {
+	rules []compiledRule
+}
+
+// compiledRule 预编译正则、统一大小写后的规则
+type compiledRule struct {
+	Rule
+	nameRe *regexp.Regexp
+}
+
+// New 创建过滤流水线，规则为空时不过滤任何仓库
+func New(rules []Rule) (*Pipeline, error) {
+	compiled := make([]compiledRule, 0, len(rules))
+	for i, rule := range rules {
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("filter-%d", i+1)
+		}
+
+		rule.Action = strings.ToLower(rule.Action)
+		if rule.Action == "" {
+			rule.Action = ActionExclude
+		}
+		if rule.Action != ActionInclude && rule.Action != ActionExclude {
+			return nil, fmt.Errorf("filter %s: invalid action: %s (must be include or exclude)", rule.Name, rule.Action)
+		}
+		// 没有条件的规则匹配所有仓库，一条空的 exclude 规则会清空所有报告
+		if !rule.HasConditions() {
+			return nil, fmt.Errorf("filter %s: no conditions set", rule.Name)
+		}
+
+		c := compiledRule{Rule: rule}
+		if rule.NameRegex != "" {
+			re, err := regexp.Compile(rule.NameRegex)
+			if err != nil {
+				return nil, fmt.Errorf("filter %s: invalid name regex: %w", rule.Name, err)
+			}
+			c.nameRe = re
+		}
+		compiled = append(compiled, c)
+	}
+
+	return &Pipeline{rules: compiled}, nil
+}
+
+// Len 规则数量
+func (p *Pipeline) Len() int {
+	if p == nil {
+		return 0
+	}
+	return len(p.rules)
+}
+
+// Apply 过滤仓库列表，返回保留的仓库和被过滤的仓库，不修改传入的切片
+func (p *Pipeline) Apply(repos []api.Repository) ([]api.Repository, []Dropped) {
+	if p.Len() == 0 {
+		return repos, nil
+	}
+
+	kept := make([]api.Repository, 0, len(repos))
+	var dropped []Dropped
+	for _, repo := range repos {
+		if rule, ok := p.firstDropping(&repo); ok {
+			dropped = append(dropped, Dropped{Repo: repo, Rule: rule.Name, Reason: rule.reason()})
+			continue
+		}
+		kept = append(kept, repo)
+	}
+	return kept, dropped
+}
+
+// firstDropping 返回第一条会过滤掉该仓库的规则
+func (p *Pipeline) firstDropping(repo *api.Repository) (*compiledRule, bool) {
+	for i := range p.rules {
+		rule := &p.rules[i]
+		matched := rule.matches(repo)
+		if (rule.Action == ActionExclude && matched) || (rule.Action == ActionInclude && !matched) {
+			return rule, true
+		}
+	}
+	return nil, false
+}
+
+// matches 判断仓库是否满足规则中的所有条件
+func (r *compiledRule) matches(repo *api.Repository) bool {
+	fullName := repo.FullName
+	if fullName == "" {
+		fullName = repo.RepoName
+	}
+
+	if len(r.Owners) > 0 && !containsFold(r.Owners, ownerOf(repo, fullName)) {
+		return false
+	}
+	if r.nameRe != nil && !r.nameRe.MatchString(fullName) {
+		return false
+	}
+	if len(r.DescriptionKeywords) > 0 && !containsKeyword(repo.Description, r.DescriptionKeywords) {
+		return false
+	}
+	if len(r.Languages) > 0 && !containsFold(r.Languages, repo.Language) {
+		return false
+	}
+	if len(r.Topics) > 0 && !anyContainsFold(r.Topics, repo.Topics) {
+		return false
+	}
+	if r.MinStars > 0 && repo.TotalStars() < r.MinStars {
+		return false
+	}
+	if r.MinForks > 0 && repo.TotalForks() < r.MinForks {
+		return false
+	}
+	if r.MinPushes > 0 && repo.Pushes < r.MinPushes {
+		return false
+	}
+	if r.MinPullRequests > 0 && repo.PullRequests < r.MinPullRequests {
+		return false
+	}
+	if r.Archived && !repo.Archived {
+		return false
+	}
+	if r.EmptyDescription && strings.TrimSpace(repo.Description) != "" {
+		return false
+	}
+	return true
+}
+
+// reason 过滤原因，未配置时根据规则动作生成
+func (r *compiledRule) reason() string {
+	if r.Reason != "" {
+		return r.Reason
+	}
+	if r.Action == ActionInclude {
+		return fmt.Sprintf("does not match include rule %s", r.Name)
+	}
+	return fmt.Sprintf("matches exclude rule %s", r.Name)
+}
+
+// ownerOf 仓库所有者，未设置时从全名中解析
+func ownerOf(repo *api.Repository, fullName string) string {
+	if repo.Owner != "" {
+		return repo.Owner
+	}
+	if idx := strings.Index(fullName, "/"); idx > 0 {
+		return fullName[:idx]
+	}
+	return ""
+}
+
+// containsFold 判断 list 中是否有与 s 相等的值（不区分大小写）
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// anyContainsFold 判断两个列表是否有相同的值（不区分大小写）
+func anyContainsFold(list []string, values []string) bool {
+	for _, value := range values {
+		if containsFold(list, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsKeyword 判断文本中是否包含任一关键字（不区分大小写）
+func containsKeyword(text string, keywords []string) bool {
+	text = strings.ToLower(text)
+	for _, keyword := range keywords {
+		if keyword != "" && strings.Contains(text, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	return false
+}
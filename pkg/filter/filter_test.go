@@ -0,0 +1,133 @@
+package filter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/github-insight-analyze/trending-notifier/pkg/api"
+)
+
+var testRepos = []api.Repository{
+	{FullName: "acme/awesome-go", Description: "A curated list", Language: "Go", Stars: 5000},
+	{FullName: "acme/tool", Description: "A CLI tool", Language: "Go", Stars: 30, Archived: true},
+	{FullName: "bob/lib", Description: "", Language: "Rust", Stars: 800, Topics: []string{"wasm"}},
+	{FullName: "carol/app", Description: "Web app", Language: "TypeScript", Stars: 5},
+}
+
+func names(repos []api.Repository) []string {
+	list := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		list = append(list, repo.FullName)
+	}
+	return list
+}
+
+func TestNewRejectsRuleWithoutConditions(t *testing.T) {
+	for _, rule := range []Rule{
+		{},
+		{Name: "empty", Action: ActionExclude, Reason: "oops"},
+		{Action: ActionInclude},
+	} {
+		if _, err := New([]Rule{{Owners: []string{"acme"}}, rule}); err == nil || !strings.Contains(err.Error(), "no conditions") {
+			t.Errorf("New(%+v) err = %v, want a no conditions error", rule, err)
+		}
+	}
+
+	if _, err := New([]Rule{{Action: "drop", Owners: []string{"acme"}}}); err == nil {
+		t.Error("New accepted an invalid action")
+	}
+	if _, err := New([]Rule{{NameRegex: "("}}); err == nil {
+		t.Error("New accepted an invalid regex")
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []Rule
+		kept    []string
+		dropped map[string]string // 仓库 -> 原因
+	}{
+		{
+			name:  "no rules",
+			kept:  []string{"acme/awesome-go", "acme/tool", "bob/lib", "carol/app"},
+			rules: nil,
+		},
+		{
+			name:    "exclude requires every condition",
+			rules:   []Rule{{Name: "acme-go", Owners: []string{"ACME"}, MinStars: 100}},
+			kept:    []string{"acme/tool", "bob/lib", "carol/app"},
+			dropped: map[string]string{"acme/awesome-go": "matches exclude rule acme-go"},
+		},
+		{
+			name:  "include keeps only matches",
+			rules: []Rule{{Name: "popular", Action: "INCLUDE", MinStars: 100}},
+			kept:  []string{"acme/awesome-go", "bob/lib"},
+			dropped: map[string]string{
+				"acme/tool": "does not match include rule popular",
+				"carol/app": "does not match include rule popular",
+			},
+		},
+		{
+			// 仓库依次经过每条规则，第一条过滤掉它的规则作为原因
+			name: "first dropping rule wins",
+			rules: []Rule{
+				{Name: "archived", Archived: true, Reason: "archived"},
+				{Name: "popular", Action: ActionInclude, MinStars: 100, Reason: "too few stars"},
+				{Name: "awesome", NameRegex: "(?i)/awesome", Reason: "awesome list"},
+			},
+			kept: []string{"bob/lib"},
+			dropped: map[string]string{
+				"acme/tool":       "archived",
+				"carol/app":       "too few stars",
+				"acme/awesome-go": "awesome list",
+			},
+		},
+		{
+			name: "include after exclude",
+			rules: []Rule{
+				{Name: "no-rust", Languages: []string{"rust"}},
+				{Name: "go-only", Action: ActionInclude, Languages: []string{"go"}},
+			},
+			kept: []string{"acme/awesome-go", "acme/tool"},
+			dropped: map[string]string{
+				"bob/lib":   "matches exclude rule no-rust",
+				"carol/app": "does not match include rule go-only",
+			},
+		},
+		{
+			name: "keywords, topics and empty description",
+			rules: []Rule{
+				{DescriptionKeywords: []string{"CURATED"}},
+				{Topics: []string{"WASM"}, EmptyDescription: true},
+			},
+			kept: []string{"acme/tool", "carol/app"},
+			dropped: map[string]string{
+				"acme/awesome-go": "matches exclude rule filter-1",
+				"bob/lib":         "matches exclude rule filter-2",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		pipeline, err := New(tt.rules)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		kept, dropped := pipeline.Apply(testRepos)
+		if got := names(kept); !reflect.DeepEqual(got, tt.kept) {
+			t.Errorf("%s: kept = %v, want %v", tt.name, got, tt.kept)
+		}
+		reasons := make(map[string]string, len(dropped))
+		for _, d := range dropped {
+			reasons[d.Repo.FullName] = d.Reason
+		}
+		if len(reasons) == 0 {
+			reasons = nil
+		}
+		if !reflect.DeepEqual(reasons, tt.dropped) {
+			t.Errorf("%s: dropped = %v, want %v", tt.name, reasons, tt.dropped)
+		}
+	}
+}
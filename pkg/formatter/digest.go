@@ -0,0 +1,89 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/github-insight-analyze/trending-notifier/pkg/api"
+	"github.com/github-insight-analyze/trending-notifier/pkg/trend"
+)
+
+// Section 多语言汇总报告中的一种语言
+type Section struct {
+	Language string
+	Repos    []api.Repository
+	Diff     *trend.ReportDiff // 该语言与上次报告的差异，为 nil 时不渲染差异部分
+}
+
+// Digest 多语言汇总报告，每种语言一节，可附带跨语言合并的总榜
+type Digest struct {
+	Period   string
+	Sections []Section
+	Overall  []api.Repository // 跨语言总榜，为空时不渲染
+}
+
+// Languages 报告包含的语言
+func (d *Digest) Languages() []string {
+	languages := make([]string, 0, len(d.Sections))
+	for _, section := range d.Sections {
+		languages = append(languages, section.Language)
+	}
+	return languages
+}
+
+// Total 各语言仓库数量之和
+func (d *Digest) Total() int {
+	total := 0
+	for _, section := range d.Sections {
+		total += len(section.Repos)
+	}
+	return total
+}
+
+// DigestFormatter 多语言汇总报告格式化器
+type DigestFormatter interface {
+	FormatDigest(digest *Digest) (string, error)
+}
+
+// FormatDigest 将多语言汇总报告格式化为纯文本
+func (f *TextFormatter) FormatDigest(digest *Digest) (string, error) {
+	return renderText(f.TemplatePath, newDigestData(digest, f.Ranking))
+}
+
+// FormatDigest 将多语言汇总报告格式化为HTML
+func (f *HTMLFormatter) FormatDigest(digest *Digest) (string, error) {
+	return renderHTML(f.TemplatePath, newDigestData(digest, f.Ranking))
+}
+
+// overallTitle 跨语言总榜标题
+func overallTitle(repos []api.Repository) string {
+	return fmt.Sprintf("Overall Top %d", len(repos))
+}
+
+// formatLanguages 格式化语言列表，如 "Go, Rust, Zig"
+func formatLanguages(languages []string) string {
+	names := make([]string, 0, len(languages))
+	for _, language := range languages {
+		names = append(names, FormatLanguage(language))
+	}
+	return strings.Join(names, ", ")
+}
+
+// sectionAnchor 语言小节的锚点，如 "lang-c-plus-plus"
+func sectionAnchor(language string) string {
+	var sb strings.Builder
+	for _, c := range strings.ToLower(language) {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+			sb.WriteRune(c)
+		case c == '+':
+			sb.WriteString("-plus-")
+		case c == '#':
+			sb.WriteString("-sharp-")
+		default:
+			sb.WriteRune('-')
+		}
+	}
+	parts := strings.FieldsFunc(sb.String(), func(c rune) bool { return c == '-' })
+	return "lang-" + strings.Join(parts, "-")
+}
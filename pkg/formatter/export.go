@@ -0,0 +1,238 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/github-insight-analyze/trending-notifier/pkg/api"
+)
+
+// SchemaVersion JSON/CSV 导出格式的版本号
+// 只增加字段或列时保持不变，删除、重命名字段或改变含义时加一
+const SchemaVersion = 1
+
+// ExportDocument JSON 导出的文档结构
+type ExportDocument struct {
+	SchemaVersion int                `json:"schema_version"`
+	Language      string             `json:"language"`  // 查询的语言，多语言报告为逗号分隔的列表
+	Period        string             `json:"period"`    // 时间范围
+	Generated     time.Time          `json:"generated"` // 生成时间（RFC 3339）
+	Source        string             `json:"source"`    // 数据源名称，如 "ossinsight"
+	Ranking       string             `json:"ranking,omitempty"`
+	Count         int                `json:"count"`
+	Repositories  []ExportRepository `json:"repositories"`
+}
+
+// ExportRepository 导出的仓库记录
+type ExportRepository struct {
+	Section      string   `json:"section"` // 所属榜单：语言名称，跨语言总榜为 "overall"
+	Rank         int      `json:"rank"`
+	PreviousRank int      `json:"previous_rank"` // 0 表示上次未上榜
+	RankDelta    int      `json:"rank_delta"`    // 正数表示上升
+	RepoID       int64    `json:"repo_id"`
+	FullName     string   `json:"full_name"`
+	Owner        string   `json:"owner"`
+	URL          string   `json:"url"`
+	Description  string   `json:"description"`
+	Language     string   `json:"language"`
+	Stars        int      `json:"stars"`
+	StarsDelta   int      `json:"stars_delta"`
+	Forks        int      `json:"forks"`
+	ForksDelta   int      `json:"forks_delta"`
+	Pushes       int      `json:"pushes"`
+	PullRequests int      `json:"pull_requests"`
+	TotalScore   float64  `json:"total_score"`
+	Topics       []string `json:"topics"`
+	Contributors []string `json:"contributors"`
+	Collections  []string `json:"collections"`
+	Sources      []string `json:"sources"` // 收录该仓库的数据源，如 "ossinsight #3"
+}
+
+// ExportSectionOverall 跨语言总榜在导出数据中的 section 名称
+const ExportSectionOverall = "overall"
+
+// JSONFormatter JSON 格式化器，输出 ExportDocument
+type JSONFormatter struct {
+	Source  string // 数据源名称
+	Ranking string // 排名策略说明，为空时省略
+}
+
+// NewJSONFormatter 创建 JSON 格式化器
+func NewJSONFormatter() *JSONFormatter {
+	return &JSONFormatter{}
+}
+
+// Format 格式化为 JSON
+func (f *JSONFormatter) Format(repos []api.Repository, language string, period string) (string, error) {
+	doc := f.newDocument(language, period)
+	doc.Repositories = exportRepositories(language, repos)
+	doc.Count = len(doc.Repositories)
+	return encodeJSON(doc)
+}
+
+// FormatDigest 将多语言汇总报告格式化为 JSON，各语言的仓库通过 section 字段区分
+func (f *JSONFormatter) FormatDigest(digest *Digest) (string, error) {
+	return encodeJSON(f.Document(digest))
+}
+
+// Document 生成汇总报告的导出文档，供 webhook 等通知渠道使用
+func (f *JSONFormatter) Document(digest *Digest) *ExportDocument {
+	doc := f.newDocument(strings.Join(digest.Languages(), ","), digest.Period)
+	doc.Repositories = exportDigest(digest)
+	doc.Count = len(doc.Repositories)
+	return doc
+}
+
+// newDocument 创建带运行元信息的文档
+func (f *JSONFormatter) newDocument(language, period string) *ExportDocument {
+	return &ExportDocument{
+		SchemaVersion: SchemaVersion,
+		Language:      language,
+		Period:        period,
+		Generated:     time.Now().UTC().Truncate(time.Second),
+		Source:        f.Source,
+		Ranking:       f.Ranking,
+		Repositories:  []ExportRepository{},
+	}
+}
+
+// encodeJSON 缩进输出，不转义 HTML 字符
+func encodeJSON(doc *ExportDocument) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return "", fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// csvColumns CSV 导出的列，每行都带有运行元信息，便于直接载入数据分析工具
+var csvColumns = []string{
+	"schema_version", "language", "period", "generated", "source", "ranking",
+	"section", "rank", "previous_rank", "rank_delta",
+	"repo_id", "full_name", "owner", "url", "description", "repo_language",
+	"stars", "stars_delta", "forks", "forks_delta", "pushes", "pull_requests", "total_score",
+	"topics", "contributors", "collections", "sources",
+}
+
+// CSVFormatter CSV 格式化器，第一行为列名，列表字段以 ";" 分隔
+type CSVFormatter struct {
+	Source  string // 数据源名称
+	Ranking string // 排名策略说明
+}
+
+// NewCSVFormatter 创建 CSV 格式化器
+func NewCSVFormatter() *CSVFormatter {
+	return &CSVFormatter{}
+}
+
+// Format 格式化为 CSV
+func (f *CSVFormatter) Format(repos []api.Repository, language string, period string) (string, error) {
+	return f.encode(language, period, exportRepositories(language, repos))
+}
+
+// FormatDigest 将多语言汇总报告格式化为 CSV，各语言的仓库通过 section 列区分
+func (f *CSVFormatter) FormatDigest(digest *Digest) (string, error) {
+	return f.encode(strings.Join(digest.Languages(), ","), digest.Period, exportDigest(digest))
+}
+
+// encode 输出列名和所有记录
+func (f *CSVFormatter) encode(language, period string, repos []ExportRepository) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	if err := w.Write(csvColumns); err != nil {
+		return "", fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	generated := time.Now().UTC().Truncate(time.Second).Format(time.RFC3339)
+	for _, repo := range repos {
+		record := []string{
+			strconv.Itoa(SchemaVersion), language, period, generated, f.Source, f.Ranking,
+			repo.Section, strconv.Itoa(repo.Rank), strconv.Itoa(repo.PreviousRank), strconv.Itoa(repo.RankDelta),
+			strconv.FormatInt(repo.RepoID, 10), repo.FullName, repo.Owner, repo.URL, repo.Description, repo.Language,
+			strconv.Itoa(repo.Stars), strconv.Itoa(repo.StarsDelta), strconv.Itoa(repo.Forks), strconv.Itoa(repo.ForksDelta),
+			strconv.Itoa(repo.Pushes), strconv.Itoa(repo.PullRequests), strconv.FormatFloat(repo.TotalScore, 'f', -1, 64),
+			strings.Join(repo.Topics, ";"), strings.Join(repo.Contributors, ";"),
+			strings.Join(repo.Collections, ";"), strings.Join(repo.Sources, ";"),
+		}
+		if err := w.Write(record); err != nil {
+			return "", fmt.Errorf("failed to write CSV record: %w", err)
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// exportDigest 展开多语言汇总报告中的所有榜单
+func exportDigest(digest *Digest) []ExportRepository {
+	var repos []ExportRepository
+	for _, section := range digest.Sections {
+		repos = append(repos, exportRepositories(section.Language, section.Repos)...)
+	}
+	repos = append(repos, exportRepositories(ExportSectionOverall, digest.Overall)...)
+	return repos
+}
+
+// exportRepositories 转换为导出记录，排名按列表顺序重新编号
+func exportRepositories(section string, repos []api.Repository) []ExportRepository {
+	result := make([]ExportRepository, 0, len(repos))
+	for i, repo := range repos {
+		fullName := repo.FullName
+		if fullName == "" {
+			fullName = repo.RepoName
+		}
+		stars := repo.Stars
+		if stars == 0 {
+			stars = repo.StargazersCount
+		}
+		forks := repo.Forks
+		if forks == 0 {
+			forks = repo.ForksCount
+		}
+
+		result = append(result, ExportRepository{
+			Section:      section,
+			Rank:         i + 1,
+			PreviousRank: repo.PreviousRank,
+			RankDelta:    repo.RankDelta,
+			RepoID:       repo.RepoID,
+			FullName:     fullName,
+			Owner:        repo.Owner,
+			URL:          repo.URL,
+			Description:  repo.Description,
+			Language:     repo.Language,
+			Stars:        stars,
+			StarsDelta:   repo.StarsDelta,
+			Forks:        forks,
+			ForksDelta:   repo.ForksDelta,
+			Pushes:       repo.Pushes,
+			PullRequests: repo.PullRequests,
+			TotalScore:   repo.TotalScore,
+			Topics:       nonNil(repo.Topics),
+			Contributors: nonNil(repo.Contributors),
+			Collections:  nonNil(repo.Collections),
+			Sources:      nonNil(formatSources(repo.Sources)),
+		})
+	}
+	return result
+}
+
+// nonNil 空列表输出为 [] 而不是 null，保持结构稳定
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
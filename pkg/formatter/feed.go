@@ -0,0 +1,368 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/github-insight-analyze/trending-notifier/pkg/api"
+)
+
+// feed 格式
+const (
+	FeedAtom = "atom"
+	FeedRSS  = "rss"
+)
+
+// 默认保留的 feed 条目数量
+const defaultFeedMaxItems = 100
+
+// FeedFormatter Atom/RSS feed 格式化器
+// 每个仓库生成一个条目，GUID 由 RepoID 和时间范围生成，同一仓库再次上榜时更新原条目；
+// 设置 Previous 后会与上一次生成的 feed 合并，按更新时间保留最近的 MaxItems 个条目
+type FeedFormatter struct {
+	Kind     string // FeedAtom 或 FeedRSS，默认 FeedAtom
+	Title    string // feed 标题
+	Link     string // feed 对应的网页地址
+	MaxItems int    // 保留的条目数量，<= 0 时使用默认值 100
+	Previous []byte // 上一次生成的 feed 内容，为空时生成新的 feed
+}
+
+// NewFeedFormatter 创建 feed 格式化器
+func NewFeedFormatter(kind string) *FeedFormatter {
+	return &FeedFormatter{Kind: kind}
+}
+
+// feedItem 与具体格式无关的 feed 条目
+type feedItem struct {
+	ID         string
+	Title      string
+	Link       string
+	Summary    string
+	Categories []string
+	Published  time.Time
+	Updated    time.Time
+}
+
+// Format 格式化为 feed
+func (f *FeedFormatter) Format(repos []api.Repository, language string, period string) (string, error) {
+	now := time.Now().UTC().Truncate(time.Second)
+	return f.render(feedItems(repos, period, now), now)
+}
+
+// FormatDigest 将多语言汇总报告格式化为 feed，跨语言总榜与各语言榜单重复，不单独生成条目
+func (f *FeedFormatter) FormatDigest(digest *Digest) (string, error) {
+	now := time.Now().UTC().Truncate(time.Second)
+	var items []feedItem
+	for _, section := range digest.Sections {
+		items = append(items, feedItems(section.Repos, digest.Period, now)...)
+	}
+	return f.render(items, now)
+}
+
+// FeedItemID feed 条目的 GUID，如 "urn:trending-notifier:daily:123456"
+// 没有 RepoID 时使用小写的仓库全名
+func FeedItemID(repo *api.Repository, period string) string {
+	if repo.RepoID != 0 {
+		return fmt.Sprintf("urn:trending-notifier:%s:%d", period, repo.RepoID)
+	}
+	return fmt.Sprintf("urn:trending-notifier:%s:%s", period, strings.ToLower(repo.Key()))
+}
+
+// feedItems 将仓库转换为 feed 条目
+func feedItems(repos []api.Repository, period string, now time.Time) []feedItem {
+	items := make([]feedItem, 0, len(repos))
+	for i, repo := range repos {
+		stars := repo.Stars
+		if stars == 0 {
+			stars = repo.StargazersCount
+		}
+		summary := fmt.Sprintf("#%d in %s · ⭐ %s", i+1, FormatPeriod(period), formatCount(stars, repo.StarsDelta))
+		if repo.Language != "" {
+			summary += " · " + repo.Language
+		}
+		if repo.Description != "" {
+			summary = repo.Description + "\n\n" + summary
+		}
+
+		var categories []string
+		if repo.Language != "" {
+			categories = append(categories, repo.Language)
+		}
+
+		title := repo.FullName
+		if title == "" {
+			title = repo.RepoName
+		}
+
+		items = append(items, feedItem{
+			ID:         FeedItemID(&repo, period),
+			Title:      title,
+			Link:       repo.URL,
+			Summary:    summary,
+			Categories: categories,
+			Published:  now,
+			Updated:    now,
+		})
+	}
+	return items
+}
+
+// render 与上一次的 feed 合并后输出
+func (f *FeedFormatter) render(items []feedItem, now time.Time) (string, error) {
+	var previous []feedItem
+	if len(bytes.TrimSpace(f.Previous)) > 0 {
+		var err error
+		previous, err = parseFeed(f.Previous)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	maxItems := f.MaxItems
+	if maxItems <= 0 {
+		maxItems = defaultFeedMaxItems
+	}
+	items = mergeFeedItems(previous, items, maxItems)
+
+	title := f.Title
+	if title == "" {
+		title = "GitHub Trending Repositories"
+	}
+
+	var doc interface{}
+	switch strings.ToLower(f.Kind) {
+	case FeedRSS:
+		doc = newRSSFeed(title, f.Link, items, now)
+	case "", FeedAtom:
+		doc = newAtomFeed(title, f.Link, items, now)
+	default:
+		return "", fmt.Errorf("unknown feed format: %s", f.Kind)
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode feed: %w", err)
+	}
+	return xml.Header + string(data) + "\n", nil
+}
+
+// mergeFeedItems 合并新旧条目：相同 GUID 的条目用新内容替换并保留首次发布时间，
+// 按更新时间从新到旧排序后保留前 maxItems 个
+func mergeFeedItems(previous, current []feedItem, maxItems int) []feedItem {
+	byID := make(map[string]int, len(previous)+len(current))
+	var merged []feedItem
+	for _, item := range previous {
+		if _, ok := byID[item.ID]; ok {
+			continue
+		}
+		byID[item.ID] = len(merged)
+		merged = append(merged, item)
+	}
+	for _, item := range current {
+		if idx, ok := byID[item.ID]; ok {
+			if !merged[idx].Published.IsZero() {
+				item.Published = merged[idx].Published
+			}
+			merged[idx] = item
+			continue
+		}
+		byID[item.ID] = len(merged)
+		merged = append(merged, item)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Updated.After(merged[j].Updated)
+	})
+	if len(merged) > maxItems {
+		merged = merged[:maxItems]
+	}
+	return merged
+}
+
+// parseFeed 解析之前生成的 Atom 或 RSS feed
+func parseFeed(data []byte) ([]feedItem, error) {
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse previous feed: %w", err)
+	}
+
+	switch root.XMLName.Local {
+	case "feed":
+		var feed atomFeed
+		if err := xml.Unmarshal(data, &feed); err != nil {
+			return nil, fmt.Errorf("failed to parse previous Atom feed: %w", err)
+		}
+		items := make([]feedItem, 0, len(feed.Entries))
+		for _, entry := range feed.Entries {
+			item := feedItem{
+				ID:      entry.ID,
+				Title:   entry.Title,
+				Link:    entry.Link.Href,
+				Summary: entry.Summary,
+			}
+			for _, category := range entry.Categories {
+				item.Categories = append(item.Categories, category.Term)
+			}
+			item.Published, _ = time.Parse(time.RFC3339, entry.Published)
+			item.Updated, _ = time.Parse(time.RFC3339, entry.Updated)
+			items = append(items, item)
+		}
+		return items, nil
+	case "rss":
+		var feed rssFeed
+		if err := xml.Unmarshal(data, &feed); err != nil {
+			return nil, fmt.Errorf("failed to parse previous RSS feed: %w", err)
+		}
+		items := make([]feedItem, 0, len(feed.Channel.Items))
+		for _, rssItem := range feed.Channel.Items {
+			item := feedItem{
+				ID:         rssItem.GUID.Value,
+				Title:      rssItem.Title,
+				Link:       rssItem.Link,
+				Summary:    rssItem.Description,
+				Categories: rssItem.Categories,
+			}
+			item.Updated, _ = time.Parse(time.RFC1123Z, rssItem.PubDate)
+			// 旧版本生成的 feed 没有首次发布时间，使用更新时间
+			if item.Published, _ = time.Parse(time.RFC1123Z, rssItem.Published); item.Published.IsZero() {
+				item.Published = item.Updated
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("failed to parse previous feed: unknown root element <%s>", root.XMLName.Local)
+	}
+}
+
+// atomFeed Atom 1.0 feed
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// atomLink Atom 链接
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+// atomCategory Atom 分类
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// atomEntry Atom 条目
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Published  string         `xml:"published,omitempty"`
+	Updated    string         `xml:"updated"`
+	Link       atomLink       `xml:"link"`
+	Summary    string         `xml:"summary"`
+	Categories []atomCategory `xml:"category"`
+}
+
+// newAtomFeed 生成 Atom feed
+func newAtomFeed(title, link string, items []feedItem, now time.Time) *atomFeed {
+	feed := &atomFeed{
+		Title:   title,
+		ID:      "urn:trending-notifier:feed",
+		Updated: now.Format(time.RFC3339),
+	}
+	if link != "" {
+		feed.ID = link
+		feed.Links = append(feed.Links, atomLink{Href: link, Rel: "alternate"})
+	}
+
+	for _, item := range items {
+		entry := atomEntry{
+			Title:   item.Title,
+			ID:      item.ID,
+			Updated: item.Updated.UTC().Format(time.RFC3339),
+			Link:    atomLink{Href: item.Link, Rel: "alternate"},
+			Summary: item.Summary,
+		}
+		if !item.Published.IsZero() {
+			entry.Published = item.Published.UTC().Format(time.RFC3339)
+		}
+		for _, category := range item.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: category})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed
+}
+
+// rssFeed RSS 2.0 feed
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+// rssChannel RSS 频道
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+// rssGUID RSS 条目 GUID，不是永久链接
+type rssGUID struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// rssItem RSS 条目，pubDate 为最近一次上榜时间
+// RSS 2.0 没有首次发布时间，用扩展元素 <published xmlns="urn:trending-notifier"> 记录，合并时据此保留首次上榜时间
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Published   string   `xml:"urn:trending-notifier published,omitempty"`
+	Categories  []string `xml:"category"`
+}
+
+// newRSSFeed 生成 RSS feed
+func newRSSFeed(title, link string, items []feedItem, now time.Time) *rssFeed {
+	feed := &rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         title,
+			Link:          link,
+			Description:   title,
+			LastBuildDate: now.Format(time.RFC1123Z),
+		},
+	}
+
+	for _, item := range items {
+		entry := rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Summary,
+			GUID:        rssGUID{IsPermaLink: "false", Value: item.ID},
+			PubDate:     item.Updated.UTC().Format(time.RFC1123Z),
+			Categories:  item.Categories,
+		}
+		if !item.Published.IsZero() {
+			entry.Published = item.Published.UTC().Format(time.RFC1123Z)
+		}
+		feed.Channel.Items = append(feed.Channel.Items, entry)
+	}
+	return feed
+}
@@ -0,0 +1,69 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFeedKeepsFirstPublished(t *testing.T) {
+	published := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	updated := time.Date(2026, 5, 4, 8, 0, 0, 0, time.UTC)
+	items := []feedItem{{ID: "urn:trending-notifier:daily:1", Title: "a/one", Published: published, Updated: updated}}
+
+	for _, kind := range []string{FeedAtom, FeedRSS} {
+		f := &FeedFormatter{Kind: kind}
+		data, err := f.render(items, updated)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := parseFeed([]byte(data))
+		if err != nil {
+			t.Fatalf("%s: %v", kind, err)
+		}
+		if len(parsed) != 1 || !parsed[0].Published.Equal(published) || !parsed[0].Updated.Equal(updated) {
+			t.Errorf("%s: parsed = %+v, want published %v and updated %v", kind, parsed, published, updated)
+		}
+
+		// 再次上榜时更新时间变化，首次发布时间不变
+		later := updated.Add(24 * time.Hour)
+		f.Previous = []byte(data)
+		data, err = f.render([]feedItem{{ID: items[0].ID, Title: "a/one", Published: later, Updated: later}}, later)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err = parseFeed([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !parsed[0].Published.Equal(published) || !parsed[0].Updated.Equal(later) {
+			t.Errorf("%s: after merge published/updated = %v/%v, want %v/%v", kind, parsed[0].Published, parsed[0].Updated, published, later)
+		}
+	}
+}
+
+func TestParseFeedRSSWithoutPublished(t *testing.T) {
+	data := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"><channel><title>t</title>
+  <item><title>a/one</title><guid isPermaLink="false">urn:trending-notifier:daily:1</guid><pubDate>Mon, 04 May 2026 08:00:00 +0000</pubDate></item>
+</channel></rss>`
+	parsed, err := parseFeed([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2026, 5, 4, 8, 0, 0, 0, time.UTC)
+	if len(parsed) != 1 || !parsed[0].Published.Equal(want) {
+		t.Errorf("parsed = %+v, want published to fall back to pubDate", parsed)
+	}
+}
+
+func TestRSSPublishedElement(t *testing.T) {
+	published := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	data, err := (&FeedFormatter{Kind: FeedRSS}).render([]feedItem{{ID: "x", Published: published, Updated: published}}, published)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<published xmlns="urn:trending-notifier">Fri, 01 May 2026 08:00:00 +0000</published>`; !strings.Contains(data, want) {
+		t.Errorf("feed does not contain %s:\n%s", want, data)
+	}
+}
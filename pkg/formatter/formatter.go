@@ -2,10 +2,11 @@ package formatter
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/github-insight-analyze/trending-notifier/pkg/api"
+	"github.com/github-insight-analyze/trending-notifier/pkg/trend"
 )
 
 // Formatter 数据格式化器
@@ -13,8 +14,12 @@ type Formatter interface {
 	Format(repos []api.Repository, language string, period string) (string, error)
 }
 
-// TextFormatter 纯文本格式化器
-type TextFormatter struct{}
+// TextFormatter 纯文本格式化器，使用内置或自定义的 text/template 模板
+type TextFormatter struct {
+	Diff         *trend.ReportDiff // 与上次报告的差异，为 nil 时不渲染差异部分
+	Ranking      string            // 排名策略说明，为空时不显示
+	TemplatePath string            // 自定义模板文件，为空时使用内置模板
+}
 
 // NewTextFormatter 创建纯文本格式化器
 func NewTextFormatter() *TextFormatter {
@@ -23,61 +28,15 @@ func NewTextFormatter() *TextFormatter {
 
 // Format 格式化为纯文本
 func (f *TextFormatter) Format(repos []api.Repository, language string, period string) (string, error) {
-	var sb strings.Builder
-
-	// 标题
-	sb.WriteString("======================================\n")
-	sb.WriteString("GitHub Trending Repositories Report\n")
-	sb.WriteString("======================================\n\n")
-
-	// 查询参数
-	sb.WriteString(fmt.Sprintf("Language: %s\n", formatLanguage(language)))
-	sb.WriteString(fmt.Sprintf("Period: %s\n", formatPeriod(period)))
-	sb.WriteString(fmt.Sprintf("Generated: %s\n", time.Now().Format("2006-01-02 15:04:05")))
-	sb.WriteString(fmt.Sprintf("Total Repositories: %d\n\n", len(repos)))
-
-	// 分隔线
-	sb.WriteString("--------------------------------------\n\n")
-
-	// 仓库列表
-	for i, repo := range repos {
-		sb.WriteString(fmt.Sprintf("#%d  %s\n", i+1, repo.RepoName))
-		sb.WriteString(fmt.Sprintf("    URL: %s\n", repo.URL))
-
-		if repo.Description != "" {
-			sb.WriteString(fmt.Sprintf("    Description: %s\n", repo.Description))
-		}
-
-		if repo.Language != "" {
-			sb.WriteString(fmt.Sprintf("    Language: %s\n", repo.Language))
-		}
-
-		sb.WriteString(fmt.Sprintf("    Stars: %d", repo.Stars))
-		if repo.StarsDelta > 0 {
-			sb.WriteString(fmt.Sprintf(" (+%d)", repo.StarsDelta))
-		}
-		sb.WriteString("\n")
-
-		sb.WriteString(fmt.Sprintf("    Forks: %d", repo.Forks))
-		if repo.ForksDelta > 0 {
-			sb.WriteString(fmt.Sprintf(" (+%d)", repo.ForksDelta))
-		}
-		sb.WriteString("\n")
-
-		sb.WriteString(fmt.Sprintf("    Pushes: %d\n", repo.Pushes))
-		sb.WriteString(fmt.Sprintf("    Pull Requests: %d\n", repo.PullRequests))
-		sb.WriteString("\n")
-	}
-
-	// 页脚
-	sb.WriteString("--------------------------------------\n")
-	sb.WriteString("Powered by OSS Insight API\n")
-
-	return sb.String(), nil
+	return renderText(f.TemplatePath, newReportData(repos, language, period, f.Ranking, f.Diff))
 }
 
-// HTMLFormatter HTML格式化器
-type HTMLFormatter struct{}
+// HTMLFormatter HTML格式化器，使用内置或自定义的 html/template 模板
+type HTMLFormatter struct {
+	Diff         *trend.ReportDiff // 与上次报告的差异，为 nil 时不渲染差异部分
+	Ranking      string            // 排名策略说明，为空时不显示
+	TemplatePath string            // 自定义模板文件，为空时使用内置模板
+}
 
 // NewHTMLFormatter 创建HTML格式化器
 func NewHTMLFormatter() *HTMLFormatter {
@@ -86,231 +45,73 @@ func NewHTMLFormatter() *HTMLFormatter {
 
 // Format 格式化为HTML
 func (f *HTMLFormatter) Format(repos []api.Repository, language string, period string) (string, error) {
-	var sb strings.Builder
-
-	// HTML头部
-	sb.WriteString(`<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>GitHub Trending Repositories Report</title>
-    <style>
-        body {
-            font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Helvetica, Arial, sans-serif;
-            line-height: 1.6;
-            color: #24292e;
-            max-width: 1200px;
-            margin: 0 auto;
-            padding: 20px;
-            background-color: #f6f8fa;
-        }
-        .container {
-            background-color: white;
-            border-radius: 6px;
-            box-shadow: 0 1px 3px rgba(0,0,0,0.12);
-            padding: 24px;
-        }
-        h1 {
-            color: #0366d6;
-            border-bottom: 2px solid #0366d6;
-            padding-bottom: 10px;
-            margin-top: 0;
-        }
-        .meta {
-            background-color: #f6f8fa;
-            padding: 12px;
-            border-radius: 6px;
-            margin: 20px 0;
-        }
-        .meta-item {
-            display: inline-block;
-            margin-right: 20px;
-        }
-        .meta-label {
-            font-weight: 600;
-            color: #586069;
-        }
-        table {
-            width: 100%;
-            border-collapse: collapse;
-            margin-top: 20px;
-        }
-        th {
-            background-color: #f6f8fa;
-            padding: 12px;
-            text-align: left;
-            font-weight: 600;
-            color: #24292e;
-            border-bottom: 2px solid #d1d5da;
-        }
-        td {
-            padding: 12px;
-            border-bottom: 1px solid #e1e4e8;
-        }
-        tr:hover {
-            background-color: #f6f8fa;
-        }
-        .rank {
-            font-weight: 600;
-            color: #0366d6;
-            width: 50px;
-        }
-        .repo-name {
-            font-weight: 600;
-        }
-        .repo-name a {
-            color: #0366d6;
-            text-decoration: none;
-        }
-        .repo-name a:hover {
-            text-decoration: underline;
-        }
-        .description {
-            color: #586069;
-            font-size: 14px;
-            margin-top: 4px;
-        }
-        .stats {
-            display: flex;
-            gap: 15px;
-            font-size: 14px;
-        }
-        .stat-item {
-            color: #586069;
-        }
-        .stat-delta {
-            color: #28a745;
-            font-weight: 600;
-        }
-        .language {
-            display: inline-block;
-            padding: 2px 8px;
-            border-radius: 3px;
-            background-color: #f1f8ff;
-            color: #0366d6;
-            font-size: 12px;
-            font-weight: 600;
-        }
-        .footer {
-            text-align: center;
-            margin-top: 30px;
-            padding-top: 20px;
-            border-top: 1px solid #e1e4e8;
-            color: #586069;
-            font-size: 14px;
-        }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <h1>🚀 GitHub Trending Repositories Report</h1>
-
-        <div class="meta">
-            <div class="meta-item">
-                <span class="meta-label">Language:</span> `)
-	sb.WriteString(formatLanguage(language))
-	sb.WriteString(`</div>
-            <div class="meta-item">
-                <span class="meta-label">Period:</span> `)
-	sb.WriteString(formatPeriod(period))
-	sb.WriteString(`</div>
-            <div class="meta-item">
-                <span class="meta-label">Generated:</span> `)
-	sb.WriteString(time.Now().Format("2006-01-02 15:04:05"))
-	sb.WriteString(`</div>
-            <div class="meta-item">
-                <span class="meta-label">Total:</span> `)
-	sb.WriteString(fmt.Sprintf("%d repositories", len(repos)))
-	sb.WriteString(`</div>
-        </div>
-
-        <table>
-            <thead>
-                <tr>
-                    <th class="rank">#</th>
-                    <th>Repository</th>
-                    <th>Language</th>
-                    <th>Stars</th>
-                    <th>Forks</th>
-                    <th>Pushes</th>
-                    <th>PRs</th>
-                </tr>
-            </thead>
-            <tbody>
-`)
-
-	// 仓库列表
-	for i, repo := range repos {
-		sb.WriteString("                <tr>\n")
-		sb.WriteString(fmt.Sprintf("                    <td class=\"rank\">%d</td>\n", i+1))
-
-		// 仓库名称和描述
-		sb.WriteString("                    <td>\n")
-		sb.WriteString(fmt.Sprintf("                        <div class=\"repo-name\"><a href=\"%s\" target=\"_blank\">%s</a></div>\n",
-			repo.URL, escapeHTML(repo.RepoName)))
-		if repo.Description != "" {
-			sb.WriteString(fmt.Sprintf("                        <div class=\"description\">%s</div>\n",
-				escapeHTML(repo.Description)))
-		}
-		sb.WriteString("                    </td>\n")
-
-		// 语言
-		sb.WriteString("                    <td>")
-		if repo.Language != "" {
-			sb.WriteString(fmt.Sprintf("<span class=\"language\">%s</span>", escapeHTML(repo.Language)))
-		} else {
-			sb.WriteString("-")
-		}
-		sb.WriteString("</td>\n")
+	return renderHTML(f.TemplatePath, newReportData(repos, language, period, f.Ranking, f.Diff))
+}
 
-		// Stars
-		sb.WriteString(fmt.Sprintf("                    <td>%s", formatNumber(repo.Stars)))
-		if repo.StarsDelta > 0 {
-			sb.WriteString(fmt.Sprintf(" <span class=\"stat-delta\">(+%s)</span>", formatNumber(repo.StarsDelta)))
-		}
-		sb.WriteString("</td>\n")
+// metaItem 报告元信息中的一项
+type metaItem struct {
+	Label string
+	Value string
+}
 
-		// Forks
-		sb.WriteString(fmt.Sprintf("                    <td>%s", formatNumber(repo.Forks)))
-		if repo.ForksDelta > 0 {
-			sb.WriteString(fmt.Sprintf(" <span class=\"stat-delta\">(+%s)</span>", formatNumber(repo.ForksDelta)))
+// withRanking 在元信息的时间范围之后插入排名策略，ranking 为空时原样返回
+func withRanking(items []metaItem, ranking string) []metaItem {
+	if ranking == "" {
+		return items
+	}
+	result := make([]metaItem, 0, len(items)+1)
+	for _, item := range items {
+		result = append(result, item)
+		if item.Label == "Period" {
+			result = append(result, metaItem{"Ranking", ranking})
 		}
-		sb.WriteString("</td>\n")
-
-		// Pushes
-		sb.WriteString(fmt.Sprintf("                    <td>%s</td>\n", formatNumber(repo.Pushes)))
+	}
+	return result
+}
 
-		// Pull Requests
-		sb.WriteString(fmt.Sprintf("                    <td>%s</td>\n", formatNumber(repo.PullRequests)))
+// 报告中最多展示的贡献者数量
+const maxContributors = 5
 
-		sb.WriteString("                </tr>\n")
+// topContributors 返回前几位贡献者
+func topContributors(logins []string) []string {
+	if len(logins) > maxContributors {
+		return logins[:maxContributors]
 	}
+	return logins
+}
 
-	// HTML尾部
-	sb.WriteString(`            </tbody>
-        </table>
+// formatScore 格式化 OSSInsight 综合得分
+func formatScore(score float64) string {
+	return fmt.Sprintf("%.1f", score)
+}
 
-        <div class="footer">
-            <p>Powered by <a href="https://api.ossinsight.io" target="_blank">OSS Insight API</a></p>
-        </div>
-    </div>
-</body>
-</html>`)
+// formatSources 格式化数据源出处，如 "ossinsight #3"
+func formatSources(sources []api.SourceRank) []string {
+	badges := make([]string, 0, len(sources))
+	for _, source := range sources {
+		badges = append(badges, fmt.Sprintf("%s #%d", source.Source, source.Rank))
+	}
+	return badges
+}
 
-	return sb.String(), nil
+// formatDiffMove 格式化仍在榜仓库的排名变化
+func formatDiffMove(entry trend.DiffEntry) string {
+	if move := formatRankMove(entry.RankDelta); move != "" {
+		return fmt.Sprintf("%s, was #%d", move, entry.PreviousRank)
+	}
+	return "unchanged"
 }
 
-// formatLanguage 格式化语言名称
-func formatLanguage(language string) string {
+// FormatLanguage 格式化语言名称
+func FormatLanguage(language string) string {
 	if language == "" || language == "all" {
 		return "All Languages"
 	}
 	return strings.Title(language)
 }
 
-// formatPeriod 格式化时间周期
-func formatPeriod(period string) string {
+// FormatPeriod 格式化时间周期
+func FormatPeriod(period string) string {
 	switch period {
 	case "daily":
 		return "Past 24 Hours"
@@ -323,14 +124,21 @@ func formatPeriod(period string) string {
 	}
 }
 
-// formatNumber 格式化数字（添加千位分隔符）
-func formatNumber(n int) string {
-	s := fmt.Sprintf("%d", n)
+// FormatNumber 格式化数字（添加千位分隔符）
+func FormatNumber(n int) string {
+	// 按 uint64 取绝对值，-n 对 math.MinInt 会溢出
+	sign := ""
+	abs := uint64(n)
+	if n < 0 {
+		sign = "-"
+		abs = -abs
+	}
+	s := strconv.FormatUint(abs, 10)
 	if len(s) <= 3 {
-		return s
+		return sign + s
 	}
 
-	var result []byte
+	result := []byte(sign)
 	for i, c := range []byte(s) {
 		if i > 0 && (len(s)-i)%3 == 0 {
 			result = append(result, ',')
@@ -340,12 +148,14 @@ func formatNumber(n int) string {
 	return string(result)
 }
 
-// escapeHTML 转义HTML特殊字符
-func escapeHTML(s string) string {
-	s = strings.ReplaceAll(s, "&", "&amp;")
-	s = strings.ReplaceAll(s, "<", "&lt;")
-	s = strings.ReplaceAll(s, ">", "&gt;")
-	s = strings.ReplaceAll(s, "\"", "&quot;")
-	s = strings.ReplaceAll(s, "'", "&#39;")
-	return s
+// formatRankMove 格式化排名变化，无变化时返回空字符串
+func formatRankMove(delta int) string {
+	switch {
+	case delta > 0:
+		return fmt.Sprintf("↑%d", delta)
+	case delta < 0:
+		return fmt.Sprintf("↓%d", -delta)
+	default:
+		return ""
+	}
 }
@@ -0,0 +1,50 @@
+package formatter
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestFormatNumber(t *testing.T) {
+	tests := map[int]string{
+		0:        "0",
+		999:      "999",
+		1000:     "1,000",
+		1234567:  "1,234,567",
+		-12:      "-12",
+		-123:     "-123",
+		-1234567: "-1,234,567",
+		-1:       "-1",
+		-999:     "-999",
+		-1000:    "-1,000",
+		-100000:  "-100,000",
+	}
+	for n, want := range tests {
+		if got := FormatNumber(n); got != want {
+			t.Errorf("FormatNumber(%d) = %q, want %q", n, got, want)
+		}
+	}
+
+	// math.MinInt 的绝对值超出 int 的范围
+	want := "-9,223,372,036,854,775,808"
+	if strconv.IntSize == 32 {
+		want = "-2,147,483,648"
+	}
+	if got := FormatNumber(math.MinInt); got != want {
+		t.Errorf("FormatNumber(math.MinInt) = %q, want %q", got, want)
+	}
+}
+
+func TestFormatLanguageAndPeriod(t *testing.T) {
+	for language, want := range map[string]string{"": "All Languages", "all": "All Languages", "go": "Go", "c++": "C++"} {
+		if got := FormatLanguage(language); got != want {
+			t.Errorf("FormatLanguage(%q) = %q, want %q", language, got, want)
+		}
+	}
+	for period, want := range map[string]string{"daily": "Past 24 Hours", "weekly": "Past Week", "monthly": "Past Month", "past_3_months": "past_3_months"} {
+		if got := FormatPeriod(period); got != want {
+			t.Errorf("FormatPeriod(%q) = %q, want %q", period, got, want)
+		}
+	}
+}
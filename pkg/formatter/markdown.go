@@ -0,0 +1,277 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/github-insight-analyze/trending-notifier/pkg/api"
+	"github.com/github-insight-analyze/trending-notifier/pkg/trend"
+)
+
+// MarkdownFormatter GitHub 风格 Markdown 格式化器，适合发布到 wiki、GitHub Discussions 和聊天工具
+type MarkdownFormatter struct {
+	Diff    *trend.ReportDiff // 与上次报告的差异，为 nil 时不渲染差异部分
+	Ranking string            // 排名策略说明，为空时不显示
+	Compact bool              // 紧凑模式：每个仓库一行，不使用表格
+}
+
+// NewMarkdownFormatter 创建 Markdown 格式化器
+func NewMarkdownFormatter() *MarkdownFormatter {
+	return &MarkdownFormatter{}
+}
+
+// Format 格式化为 Markdown
+func (f *MarkdownFormatter) Format(repos []api.Repository, language string, period string) (string, error) {
+	var sb strings.Builder
+
+	// 标题和查询参数
+	sb.WriteString("# 🚀 GitHub Trending Repositories Report\n\n")
+	f.writeMeta(&sb, []metaItem{
+		{"Language", FormatLanguage(language)},
+		{"Period", FormatPeriod(period)},
+		{"Generated", time.Now().Format("2006-01-02 15:04:05")},
+		{"Total", fmt.Sprintf("%d repositories", len(repos))},
+	})
+
+	// 与上次报告的差异
+	if !f.Diff.IsEmpty() {
+		writeMarkdownDiff(&sb, f.Diff, "##")
+	}
+
+	// 仓库列表
+	f.writeRepos(&sb, repos)
+
+	// 页脚
+	writeMarkdownFooter(&sb)
+
+	return sb.String(), nil
+}
+
+// FormatDigest 将多语言汇总报告格式化为 Markdown
+func (f *MarkdownFormatter) FormatDigest(digest *Digest) (string, error) {
+	var sb strings.Builder
+
+	// 标题和查询参数
+	sb.WriteString("# 🚀 GitHub Trending Repositories Report\n\n")
+	f.writeMeta(&sb, []metaItem{
+		{"Languages", formatLanguages(digest.Languages())},
+		{"Period", FormatPeriod(digest.Period)},
+		{"Generated", time.Now().Format("2006-01-02 15:04:05")},
+		{"Total", fmt.Sprintf("%d repositories", digest.Total())},
+	})
+
+	// 目录，链接指向 GitHub 为标题生成的锚点
+	sb.WriteString("## Contents\n\n")
+	for _, section := range digest.Sections {
+		title := FormatLanguage(section.Language)
+		sb.WriteString(fmt.Sprintf("- [%s](#%s) (%d)\n", escapeMarkdown(title), markdownAnchor(title), len(section.Repos)))
+	}
+	if len(digest.Overall) > 0 {
+		title := overallTitle(digest.Overall)
+		sb.WriteString(fmt.Sprintf("- [%s](#%s)\n", title, markdownAnchor(title)))
+	}
+	sb.WriteString("\n")
+
+	// 各语言
+	for _, section := range digest.Sections {
+		sb.WriteString(fmt.Sprintf("## %s\n\n", escapeMarkdown(FormatLanguage(section.Language))))
+		if !section.Diff.IsEmpty() {
+			writeMarkdownDiff(&sb, section.Diff, "###")
+		}
+		f.writeRepos(&sb, section.Repos)
+	}
+
+	// 跨语言总榜
+	if len(digest.Overall) > 0 {
+		sb.WriteString(fmt.Sprintf("## %s\n\n", overallTitle(digest.Overall)))
+		f.writeRepos(&sb, digest.Overall)
+	}
+
+	// 页脚
+	writeMarkdownFooter(&sb)
+
+	return sb.String(), nil
+}
+
+// writeMeta 以一行粗体标签渲染报告元信息
+func (f *MarkdownFormatter) writeMeta(sb *strings.Builder, items []metaItem) {
+	items = withRanking(items, f.Ranking)
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		parts = append(parts, fmt.Sprintf("**%s:** %s", item.Label, escapeMarkdown(item.Value)))
+	}
+	sb.WriteString(strings.Join(parts, " · "))
+	sb.WriteString("\n\n")
+}
+
+// writeRepos 按模式渲染仓库列表
+func (f *MarkdownFormatter) writeRepos(sb *strings.Builder, repos []api.Repository) {
+	if f.Compact {
+		writeMarkdownList(sb, repos)
+	} else {
+		writeMarkdownTable(sb, repos)
+	}
+}
+
+// writeMarkdownTable 以 GFM 表格渲染仓库列表
+func writeMarkdownTable(sb *strings.Builder, repos []api.Repository) {
+	sb.WriteString("| # | Repository | Language | Stars | Forks | Pushes | PRs |\n")
+	sb.WriteString("|---:|---|---|---:|---:|---:|---:|\n")
+
+	for i, repo := range repos {
+		rank := fmt.Sprintf("%d", i+1)
+		if move := formatRankMove(repo.RankDelta); move != "" {
+			rank += " " + move
+		}
+
+		// 仓库名称、描述和附加信息，单元格内用 <br> 换行
+		cell := markdownLink(repo.RepoName, repo.URL)
+		if repo.Description != "" {
+			cell += "<br>" + escapeMarkdown(repo.Description)
+		}
+		if details := markdownDetails(&repo); details != "" {
+			cell += "<br>" + details
+		}
+
+		language := "-"
+		if repo.Language != "" {
+			language = escapeMarkdown(repo.Language)
+		}
+
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s | %s | %s |\n",
+			rank, cell, language,
+			formatCount(repo.Stars, repo.StarsDelta), formatCount(repo.Forks, repo.ForksDelta),
+			FormatNumber(repo.Pushes), FormatNumber(repo.PullRequests)))
+	}
+	sb.WriteString("\n")
+}
+
+// writeMarkdownList 紧凑模式：每个仓库一行
+func writeMarkdownList(sb *strings.Builder, repos []api.Repository) {
+	for i, repo := range repos {
+		line := fmt.Sprintf("%d. %s", i+1, markdownLink(repo.RepoName, repo.URL))
+		if move := formatRankMove(repo.RankDelta); move != "" {
+			line += " " + move
+		}
+		line += " — ⭐ " + formatCount(repo.Stars, repo.StarsDelta)
+		if repo.Language != "" {
+			line += " · " + escapeMarkdown(repo.Language)
+		}
+		if repo.Description != "" {
+			line += " — " + escapeMarkdown(repo.Description)
+		}
+		sb.WriteString(line + "\n")
+	}
+	sb.WriteString("\n")
+}
+
+// writeMarkdownDiff 以 Markdown 渲染差异部分，heading 为小节标题的级别，如 "##"
+func writeMarkdownDiff(sb *strings.Builder, diff *trend.ReportDiff, heading string) {
+	sb.WriteString(fmt.Sprintf("%s What's Changed\n\n", heading))
+	sb.WriteString(fmt.Sprintf("_Changes since the report of %s_\n\n", diff.BaselineTime.Local().Format("2006-01-02 15:04")))
+
+	if len(diff.New) > 0 {
+		sb.WriteString(fmt.Sprintf("**🆕 New Entries (%d)**\n\n", len(diff.New)))
+		for _, entry := range diff.New {
+			sb.WriteString(fmt.Sprintf("- #%d %s\n", entry.Rank, markdownLink(entry.Repo.RepoName, entry.Repo.URL)))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(diff.Still) > 0 {
+		sb.WriteString(fmt.Sprintf("**📈 Still Trending (%d)**\n\n", len(diff.Still)))
+		for _, entry := range diff.Still {
+			sb.WriteString(fmt.Sprintf("- #%d %s (%s)\n", entry.Rank, markdownLink(entry.Repo.RepoName, entry.Repo.URL), formatDiffMove(entry)))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(diff.Dropped) > 0 {
+		sb.WriteString(fmt.Sprintf("**📉 Dropped Out (%d)**\n\n", len(diff.Dropped)))
+		for _, entry := range diff.Dropped {
+			sb.WriteString(fmt.Sprintf("- %s (was #%d)\n", markdownLink(entry.Repo.RepoName, entry.Repo.URL), entry.PreviousRank))
+		}
+		sb.WriteString("\n")
+	}
+}
+
+// writeMarkdownFooter 写入 Markdown 报告页脚
+func writeMarkdownFooter(sb *strings.Builder) {
+	sb.WriteString("---\n\n")
+	sb.WriteString("Powered by [OSS Insight API](https://api.ossinsight.io)\n")
+}
+
+// markdownDetails 综合得分、贡献者、collection、数据源等附加信息，以 " · " 分隔
+func markdownDetails(repo *api.Repository) string {
+	var parts []string
+	if repo.TotalScore > 0 {
+		parts = append(parts, "Score "+formatScore(repo.TotalScore))
+	}
+	if len(repo.Contributors) > 0 {
+		parts = append(parts, "Top contributors: "+escapeMarkdown(strings.Join(topContributors(repo.Contributors), ", ")))
+	}
+	if len(repo.Collections) > 0 {
+		parts = append(parts, "Collections: "+escapeMarkdown(strings.Join(repo.Collections, ", ")))
+	}
+	if len(repo.Sources) > 0 {
+		parts = append(parts, "Sources: "+escapeMarkdown(strings.Join(formatSources(repo.Sources), ", ")))
+	}
+	if len(repo.BuiltBy) > 0 {
+		logins := make([]string, 0, len(repo.BuiltBy))
+		for _, c := range repo.BuiltBy {
+			logins = append(logins, c.Login)
+		}
+		parts = append(parts, "Built by: "+escapeMarkdown(strings.Join(logins, ", ")))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// formatCount 格式化数量及增量，如 "1,234 (+56)"
+func formatCount(n, delta int) string {
+	if delta > 0 {
+		return fmt.Sprintf("%s (+%s)", FormatNumber(n), FormatNumber(delta))
+	}
+	return FormatNumber(n)
+}
+
+// markdownLink 生成 Markdown 链接
+func markdownLink(text, url string) string {
+	url = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29").Replace(url)
+	return fmt.Sprintf("[%s](%s)", escapeMarkdown(text), url)
+}
+
+// markdownEscaper 转义 Markdown 特殊字符，表格中的 | 和行内代码的 ` 也会被转义
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	"|", `\|`,
+	"*", `\*`,
+	"_", `\_`,
+	"[", `\[`,
+	"]", `\]`,
+	"<", "&lt;",
+	">", "&gt;",
+	"\r\n", " ",
+	"\n", " ",
+)
+
+// escapeMarkdown 转义 Markdown 特殊字符并合并换行，保证内容不破坏表格和链接
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// markdownAnchor GitHub 为标题生成的锚点：小写，去掉标点，空格替换为 "-"
+func markdownAnchor(title string) string {
+	var sb strings.Builder
+	for _, c := range strings.ToLower(title) {
+		switch {
+		case c == ' ':
+			sb.WriteRune('-')
+		case c == '-' || c == '_', unicode.IsLetter(c), unicode.IsDigit(c):
+			sb.WriteRune(c)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,150 @@
+package formatter
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/github-insight-analyze/trending-notifier/pkg/api"
+	"github.com/github-insight-analyze/trending-notifier/pkg/trend"
+)
+
+// 内置的报告模板
+//
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+// 内置模板文件名和入口模板名称
+const (
+	htmlTemplateFile = "templates/report.html.tmpl"
+	textTemplateFile = "templates/report.txt.tmpl"
+	rootTemplate     = "report"
+)
+
+// reportTitle 报告标题
+const reportTitle = "GitHub Trending Repositories Report"
+
+// ReportData HTML 和纯文本报告模板的数据
+//
+// 单语言报告使用 Language、Repos 和 Diff，Sections 为空；
+// 多语言汇总报告使用 Languages、Sections 和 Overall，Language、Repos 和 Diff 为空。
+// 仓库的 StarsDelta、ForksDelta、RankDelta 为与上一次快照相比的增量。
+type ReportData struct {
+	Title     string            // 报告标题
+	Language  string            // 单语言报告的语言，如 "go"、"all"
+	Languages []string          // 报告包含的所有语言
+	Period    string            // 时间范围，如 "daily"
+	Ranking   string            // 排名策略说明，为空时不显示
+	Generated time.Time         // 生成时间
+	Total     int               // 仓库总数
+	Repos     []api.Repository  // 单语言报告的仓库，已按排名排序
+	Diff      *trend.ReportDiff // 单语言报告与上次报告的差异，可能为 nil
+	Sections  []Section         // 多语言汇总报告的各语言
+	Overall   []api.Repository  // 跨语言总榜，可能为空
+}
+
+// newReportData 单语言报告的模板数据
+func newReportData(repos []api.Repository, language, period, ranking string, diff *trend.ReportDiff) *ReportData {
+	return &ReportData{
+		Title:     reportTitle,
+		Language:  language,
+		Languages: []string{language},
+		Period:    period,
+		Ranking:   ranking,
+		Generated: time.Now(),
+		Total:     len(repos),
+		Repos:     repos,
+		Diff:      diff,
+	}
+}
+
+// newDigestData 多语言汇总报告的模板数据
+func newDigestData(digest *Digest, ranking string) *ReportData {
+	return &ReportData{
+		Title:     reportTitle,
+		Languages: digest.Languages(),
+		Period:    digest.Period,
+		Ranking:   ranking,
+		Generated: time.Now(),
+		Total:     digest.Total(),
+		Sections:  digest.Sections,
+		Overall:   digest.Overall,
+	}
+}
+
+// templateFuncs 模板中可用的函数
+var templateFuncs = map[string]interface{}{
+	"add":          func(a, b int) int { return a + b },
+	"join":         strings.Join,
+	"number":       FormatNumber,
+	"score":        formatScore,
+	"language":     FormatLanguage,
+	"languages":    formatLanguages,
+	"period":       FormatPeriod,
+	"rankMove":     formatRankMove,
+	"diffMove":     formatDiffMove,
+	"contributors": topContributors,
+	"sources":      formatSources,
+	"anchor":       sectionAnchor,
+	"overallTitle": overallTitle,
+}
+
+// renderHTML 使用内置 HTML 模板渲染报告，path 不为空时先加载自定义模板
+func renderHTML(path string, data *ReportData) (string, error) {
+	tmpl, err := htmltemplate.New(rootTemplate).Funcs(templateFuncs).ParseFS(builtinTemplates, htmlTemplateFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse built-in HTML template: %w", err)
+	}
+
+	root := rootTemplate
+	if path != "" {
+		if tmpl, err = tmpl.ParseFiles(path); err != nil {
+			return "", fmt.Errorf("failed to parse template %s: %w", path, err)
+		}
+		if t := tmpl.Lookup(filepath.Base(path)); t != nil && !isEmptyTemplate(t.Tree) {
+			root = t.Name()
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, root, data); err != nil {
+		return "", fmt.Errorf("failed to render HTML template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// renderText 使用内置纯文本模板渲染报告，path 不为空时先加载自定义模板
+func renderText(path string, data *ReportData) (string, error) {
+	tmpl, err := texttemplate.New(rootTemplate).Funcs(templateFuncs).ParseFS(builtinTemplates, textTemplateFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse built-in text template: %w", err)
+	}
+
+	root := rootTemplate
+	if path != "" {
+		if tmpl, err = tmpl.ParseFiles(path); err != nil {
+			return "", fmt.Errorf("failed to parse template %s: %w", path, err)
+		}
+		if t := tmpl.Lookup(filepath.Base(path)); t != nil && !isEmptyTemplate(t.Tree) {
+			root = t.Name()
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, root, data); err != nil {
+		return "", fmt.Errorf("failed to render text template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// isEmptyTemplate 自定义模板文件是否只包含 {{define}} 块
+// 只包含 define 块时覆盖内置模板中的同名块，否则整个文件作为报告模板
+func isEmptyTemplate(tree *parse.Tree) bool {
+	return tree == nil || tree.Root == nil || parse.IsEmptyTree(tree.Root)
+}
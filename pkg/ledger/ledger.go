@@ -0,0 +1,213 @@
+package ledger
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ChannelPrefix 邮件之外的通知渠道在账本中的收件人前缀，如 "channel:slack"
+const ChannelPrefix = "channel:"
+
+// Ledger 发送账本，记录每个报告在每个时间窗口内已经发送给了哪些收件人
+// 文件为 JSON-lines 格式，每行一条记录，只追加不修改
+type Ledger struct {
+	path    string
+	entries []Entry
+	index   map[key]int // 每个 (报告, 窗口, 收件人) 最近一条记录在 entries 中的位置
+}
+
+// Entry 一条发送记录
+type Entry struct {
+	Report    string    `json:"report"`
+	Window    string    `json:"window"`    // 时间窗口，如 "2024-01-02"、"2024-W01"、"2024-01"
+	Recipient string    `json:"recipient"` // 邮件地址（小写），或 "channel:<名称>"
+	SentAt    time.Time `json:"sent_at"`
+	Subject   string    `json:"subject,omitempty"`
+	Forced    bool      `json:"forced,omitempty"` // 使用 -force 重新发送
+}
+
+// Filter 查询条件，空值表示不限制
+type Filter struct {
+	Report    string
+	Window    string
+	Recipient string
+	Since     time.Time
+}
+
+type key struct {
+	report    string
+	window    string
+	recipient string
+}
+
+// Open 读取账本文件，文件不存在时返回空账本
+// 进程在写入过程中退出可能留下不完整的最后一行，读取时跳过并打印警告
+func Open(path string) (*Ledger, error) {
+	if path == "" {
+		return nil, fmt.Errorf("ledger path is required")
+	}
+	l := &Ledger{path: path, index: make(map[key]int)}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ledger: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			log.Printf("Warning: skipping malformed ledger entry at %s:%d: %v", path, line, err)
+			continue
+		}
+		l.add(entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ledger: %w", err)
+	}
+	return l, nil
+}
+
+// Path 账本文件路径
+func (l *Ledger) Path() string {
+	return l.path
+}
+
+// Sent 查询报告在该窗口内是否已经发送给收件人，返回最近一条记录
+func (l *Ledger) Sent(report, window, recipient string) (Entry, bool) {
+	i, ok := l.index[newKey(report, window, recipient)]
+	if !ok {
+		return Entry{}, false
+	}
+	return l.entries[i], true
+}
+
+// Record 追加发送记录，一次写入一批记录
+func (l *Ledger) Record(entries ...Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	var buf strings.Builder
+	for _, entry := range entries {
+		entry.Recipient = NormalizeRecipient(entry.Recipient)
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to encode ledger entry: %w", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create ledger directory: %w", err)
+	}
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open ledger: %w", err)
+	}
+	if _, err := file.WriteString(buf.String()); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write ledger: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write ledger: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write ledger: %w", err)
+	}
+
+	for _, entry := range entries {
+		entry.Recipient = NormalizeRecipient(entry.Recipient)
+		l.add(entry)
+	}
+	return nil
+}
+
+// List 按发送时间从新到旧返回符合条件的记录
+func (l *Ledger) List(filter Filter) []Entry {
+	recipient := ""
+	if filter.Recipient != "" {
+		recipient = NormalizeRecipient(filter.Recipient)
+	}
+
+	var entries []Entry
+	for _, entry := range l.entries {
+		if filter.Report != "" && entry.Report != filter.Report {
+			continue
+		}
+		if filter.Window != "" && entry.Window != filter.Window {
+			continue
+		}
+		if recipient != "" && entry.Recipient != recipient {
+			continue
+		}
+		if !filter.Since.IsZero() && entry.SentAt.Before(filter.Since) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].SentAt.After(entries[j].SentAt)
+	})
+	return entries
+}
+
+func (l *Ledger) add(entry Entry) {
+	l.entries = append(l.entries, entry)
+	l.index[newKey(entry.Report, entry.Window, entry.Recipient)] = len(l.entries) - 1
+}
+
+func newKey(report, window, recipient string) key {
+	return key{report: report, window: window, recipient: NormalizeRecipient(recipient)}
+}
+
+// Window 报告在 t 所在的时间窗口：daily 为日期，weekly 为 ISO 周，monthly 为月份
+// t 所在的时区决定窗口的边界
+func Window(period string, t time.Time) string {
+	switch period {
+	case "weekly":
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case "monthly":
+		return t.Format("2006-01")
+	default:
+		return t.Format("2006-01-02")
+	}
+}
+
+// ChannelRecipient 通知渠道在账本中的收件人
+func ChannelRecipient(name string) string {
+	return ChannelPrefix + name
+}
+
+// NormalizeRecipient 邮件地址去掉显示名称并转为小写，"Alice <Alice@Example.com>" 与 "alice@example.com" 视为同一收件人
+func NormalizeRecipient(recipient string) string {
+	recipient = strings.TrimSpace(recipient)
+	if strings.HasPrefix(recipient, ChannelPrefix) {
+		return recipient
+	}
+	if addr, err := mail.ParseAddress(recipient); err == nil {
+		recipient = addr.Address
+	}
+	return strings.ToLower(recipient)
+}
@@ -0,0 +1,121 @@
+package ledger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestWindow(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	utc := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name   string
+		period string
+		t      time.Time
+		want   string
+	}{
+		{"daily before midnight", "daily", utc(5, 4, 15, 59).In(shanghai), "2026-05-04"},
+		{"daily at midnight", "daily", utc(5, 4, 16, 0).In(shanghai), "2026-05-05"},
+		{"daily same instant in utc", "daily", utc(5, 4, 16, 0), "2026-05-04"},
+		{"daily behind utc", "daily", utc(5, 5, 3, 0).In(newYork), "2026-05-04"},
+		{"weekly sunday night", "weekly", utc(5, 10, 15, 59).In(shanghai), "2026-W19"},
+		{"weekly monday", "weekly", utc(5, 10, 16, 0).In(shanghai), "2026-W20"},
+		// 2027-01-01 属于 2026 年的第 53 周
+		{"weekly iso year", "weekly", time.Date(2027, 1, 1, 8, 0, 0, 0, shanghai), "2026-W53"},
+		{"weekly iso week 1", "weekly", time.Date(2027, 1, 4, 0, 0, 0, 0, shanghai), "2027-W01"},
+		{"monthly last day", "monthly", utc(5, 31, 15, 59).In(shanghai), "2026-05"},
+		{"monthly first day", "monthly", utc(5, 31, 16, 0).In(shanghai), "2026-06"},
+		{"unknown period is daily", "past_3_months", utc(5, 4, 16, 0).In(shanghai), "2026-05-05"},
+	}
+
+	for _, tt := range tests {
+		if got := Window(tt.period, tt.t); got != tt.want {
+			t.Errorf("%s: Window(%s, %v) = %q, want %q", tt.name, tt.period, tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestLedgerRecordAndSent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "ledger.jsonl")
+	l, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sentAt := time.Date(2026, 5, 5, 7, 30, 0, 0, time.UTC)
+	err = l.Record(
+		Entry{Report: "daily", Window: "2026-05-05", Recipient: "Alice <Alice@Example.com>", SentAt: sentAt},
+		Entry{Report: "daily", Window: "2026-05-05", Recipient: ChannelRecipient("slack"), SentAt: sentAt},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Record(Entry{Report: "daily", Window: "2026-05-05", Recipient: "alice@example.com", SentAt: sentAt.Add(time.Hour), Forced: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟写入过程中退出留下的不完整行
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"report":"daily","window":"2026-05-0`)
+	file.Close()
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, lg := range []*Ledger{l, reopened} {
+		entry, ok := lg.Sent("daily", "2026-05-05", "ALICE@example.com")
+		if !ok || !entry.Forced || !entry.SentAt.Equal(sentAt.Add(time.Hour)) {
+			t.Errorf("Sent(alice) = %+v, %v, want the latest forced entry", entry, ok)
+		}
+		if _, ok := lg.Sent("daily", "2026-05-05", ChannelRecipient("slack")); !ok {
+			t.Error("Sent(channel:slack) = false, want true")
+		}
+		if _, ok := lg.Sent("daily", "2026-05-06", "alice@example.com"); ok {
+			t.Error("Sent in the next window = true, want false")
+		}
+		if _, ok := lg.Sent("weekly", "2026-05-05", "alice@example.com"); ok {
+			t.Error("Sent for another report = true, want false")
+		}
+	}
+
+	entries := reopened.List(Filter{Recipient: "Alice <alice@example.com>"})
+	if len(entries) != 2 || !entries[0].Forced || entries[0].Recipient != "alice@example.com" {
+		t.Errorf("List(alice) = %+v, want two entries, newest first", entries)
+	}
+	if entries := reopened.List(Filter{Since: sentAt.Add(time.Minute)}); len(entries) != 1 {
+		t.Errorf("List(since) = %+v, want one entry", entries)
+	}
+}
+
+func TestNormalizeRecipient(t *testing.T) {
+	tests := map[string]string{
+		"alice@example.com":         "alice@example.com",
+		" Alice@Example.COM ":       "alice@example.com",
+		"Alice <Alice@Example.com>": "alice@example.com",
+		"张三 <ZhangSan@example.com>": "zhangsan@example.com",
+		"channel:Slack":             "channel:Slack",
+		"not an address":            "not an address",
+	}
+	for in, want := range tests {
+		if got := NormalizeRecipient(in); got != want {
+			t.Errorf("NormalizeRecipient(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// 钉钉 markdown 消息的最大长度（字节）
+const dingTalkMaxText = 20000
+
+// DingTalkNotifier 钉钉群机器人，发送 markdown 消息
+type DingTalkNotifier struct {
+	*webhook
+	secret   string // 加签密钥，为空表示机器人未开启加签
+	maxItems int
+}
+
+// dingTalkMessage 钉钉 markdown 消息
+type dingTalkMessage struct {
+	MsgType  string `json:"msgtype"`
+	Markdown struct {
+		Title string `json:"title"` // 会话列表中显示的标题
+		Text  string `json:"text"`
+	} `json:"markdown"`
+}
+
+// Notify 发送报告，钉钉出错时同样返回 200，错误码在响应的 errcode 中
+func (d *DingTalkNotifier) Notify(ctx context.Context, n *Notification) error {
+	msg := &dingTalkMessage{MsgType: "markdown"}
+	msg.Markdown.Title = n.Subject
+	text := fmt.Sprintf("### %s\n\n%s", n.Subject, markdownSummary(n, d.maxItems))
+	if footer := summaryFooter(n); footer != "" {
+		text += "\n> " + footer + "\n"
+	}
+	msg.Markdown.Text = truncateLines(text, dingTalkMaxText)
+
+	rawURL, err := d.signedURL(time.Now())
+	if err != nil {
+		return err
+	}
+	body, err := d.post(ctx, rawURL, msg, nil)
+	if err != nil {
+		return err
+	}
+	return d.checkErrCode(body)
+}
+
+// signedURL 开启加签时在 URL 中附加 timestamp 和 sign
+// sign = Base64(HmacSHA256(secret, timestamp + "\n" + secret))，timestamp 为毫秒
+func (d *DingTalkNotifier) signedURL(now time.Time) (string, error) {
+	if d.secret == "" {
+		return d.url, nil
+	}
+
+	u, err := url.Parse(d.url)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s URL: %w", d.name, err)
+	}
+	timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte(d.secret))
+	mac.Write([]byte(timestamp + "\n" + d.secret))
+
+	query := u.Query()
+	query.Set("timestamp", timestamp)
+	query.Set("sign", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
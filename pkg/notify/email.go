@@ -0,0 +1,44 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/github-insight-analyze/trending-notifier/pkg/email"
+)
+
+// EmailNotifier 通过 SMTP 发送邮件，收件人取自 Notification 的 To、Cc 和 Bcc
+type EmailNotifier struct {
+	client          *email.Client
+	replyTo         []string
+	listUnsubscribe []string
+}
+
+// NewEmailNotifier 创建邮件通知渠道，replyTo 和 listUnsubscribe 对所有报告生效
+func NewEmailNotifier(client *email.Client, replyTo, listUnsubscribe []string) *EmailNotifier {
+	return &EmailNotifier{
+		client:          client,
+		replyTo:         replyTo,
+		listUnsubscribe: listUnsubscribe,
+	}
+}
+
+// Name 渠道名称
+func (e *EmailNotifier) Name() string {
+	return TypeEmail
+}
+
+// Notify 发送邮件，HTML 报告带有纯文本版本时以 multipart/alternative 发送，ctx 取消时中断发送
+func (e *EmailNotifier) Notify(ctx context.Context, n *Notification) error {
+	msg := &email.Message{
+		To:              n.To,
+		Cc:              n.Cc,
+		Bcc:             n.Bcc,
+		ReplyTo:         e.replyTo,
+		Subject:         n.Subject,
+		Body:            n.Body,
+		IsHTML:          n.IsHTML,
+		TextBody:        n.TextBody,
+		ListUnsubscribe: e.listUnsubscribe,
+	}
+	return e.client.SendContext(ctx, msg)
+}
@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/github-insight-analyze/trending-notifier/pkg/email"
+)
+
+func TestEmailNotifierHonorsContext(t *testing.T) {
+	// 接受连接但不发送问候的 SMTP 服务器
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	port := ln.Addr().(*net.TCPAddr).Port
+	client, err := email.NewClientWithOptions("127.0.0.1", port, "", "", "bot@example.com",
+		email.Options{TLSMode: email.TLSNone, Timeout: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := NewEmailNotifier(client, nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	err = n.Notify(ctx, &Notification{To: []string{"to@example.com"}, Subject: "Trending", Body: "report"})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Notify returned after %v", elapsed)
+	}
+}
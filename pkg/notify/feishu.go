@@ -0,0 +1,100 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// 飞书卡片内容的最大长度（字节），请求体不能超过 30KB
+const feishuMaxContent = 20000
+
+// FeishuNotifier 飞书/Lark 群机器人，发送消息卡片
+type FeishuNotifier struct {
+	*webhook
+	secret   string // 签名校验密钥，为空表示机器人未开启签名校验
+	maxItems int
+}
+
+// feishuMessage 飞书消息卡片
+type feishuMessage struct {
+	Timestamp string     `json:"timestamp,omitempty"`
+	Sign      string     `json:"sign,omitempty"`
+	MsgType   string     `json:"msg_type"`
+	Card      feishuCard `json:"card"`
+}
+
+// feishuCard 卡片，标题加一个 lark_md 文本块和一个备注
+type feishuCard struct {
+	Config struct {
+		WideScreenMode bool `json:"wide_screen_mode"`
+	} `json:"config"`
+	Header struct {
+		Title    feishuText `json:"title"`
+		Template string     `json:"template"`
+	} `json:"header"`
+	Elements []feishuElement `json:"elements"`
+}
+
+// feishuElement 卡片元素，只用到 div 和 note
+type feishuElement struct {
+	Tag      string       `json:"tag"`
+	Text     *feishuText  `json:"text,omitempty"`
+	Elements []feishuText `json:"elements,omitempty"`
+}
+
+// feishuText 文本，tag 为 "plain_text" 或 "lark_md"
+type feishuText struct {
+	Tag     string `json:"tag"`
+	Content string `json:"content"`
+}
+
+// Notify 发送报告，飞书出错时同样返回 200，错误码在响应的 code 中
+func (f *FeishuNotifier) Notify(ctx context.Context, n *Notification) error {
+	msg := &feishuMessage{MsgType: "interactive"}
+	msg.Card.Config.WideScreenMode = true
+	msg.Card.Header.Title = feishuText{Tag: "plain_text", Content: n.Subject}
+	msg.Card.Header.Template = "blue"
+	msg.Card.Elements = []feishuElement{{
+		Tag:  "div",
+		Text: &feishuText{Tag: "lark_md", Content: truncateLines(markdownSummary(n, f.maxItems), feishuMaxContent)},
+	}}
+	if footer := summaryFooter(n); footer != "" {
+		msg.Card.Elements = append(msg.Card.Elements, feishuElement{
+			Tag:      "note",
+			Elements: []feishuText{{Tag: "plain_text", Content: footer}},
+		})
+	}
+	if f.secret != "" {
+		msg.Timestamp, msg.Sign = f.sign(time.Now())
+	}
+
+	body, err := f.post(ctx, f.url, msg, nil)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", f.name, err)
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("%s returned error %d: %s", f.name, result.Code, result.Msg)
+	}
+	return nil
+}
+
+// sign 签名校验：以 timestamp + "\n" + secret 为密钥对空字符串做 HmacSHA256，timestamp 为秒
+func (f *FeishuNotifier) sign(now time.Time) (string, string) {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(timestamp+"\n"+f.secret))
+	return timestamp, base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
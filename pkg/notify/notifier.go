@@ -0,0 +1,173 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/github-insight-analyze/trending-notifier/pkg/formatter"
+)
+
+// 通知渠道类型
+const (
+	TypeEmail    = "email"
+	TypeWebhook  = "webhook"
+	TypeSlack    = "slack"
+	TypeTeams    = "teams"
+	TypeDingTalk = "dingtalk"
+	TypeFeishu   = "feishu"
+	TypeWeCom    = "wecom"
+)
+
+// 聊天消息中每个榜单默认展示的仓库数量
+const defaultMaxItems = 10
+
+// 默认请求超时时间
+const defaultTimeout = 30 * time.Second
+
+// 读取响应体的最大长度
+const maxResponseSize = 64 << 10
+
+const userAgent = "trending-notifier/1.0"
+
+// Notifier 通知渠道
+type Notifier interface {
+	// Name 渠道名称，用于日志和运行结果
+	Name() string
+	// Notify 发送一份报告
+	Notify(ctx context.Context, n *Notification) error
+}
+
+// Notification 一份待发送的报告
+type Notification struct {
+	Report   string                    // 报告名称
+	Subject  string                    // 标题，邮件主题或聊天消息标题
+	Body     string                    // 按报告格式生成的正文
+	IsHTML   bool                      // 正文是否为 HTML
+	TextBody string                    // HTML 正文的纯文本版本，可为空
+	Document *formatter.ExportDocument // 结构化的报告数据，聊天消息和 webhook 据此生成内容
+	To       []string                  // 邮件收件人，仅邮件渠道使用
+	Cc       []string                  // 邮件抄送，仅邮件渠道使用
+	Bcc      []string                  // 邮件密送，仅邮件渠道使用
+}
+
+// Options 创建 HTTP 通知渠道的参数
+type Options struct {
+	Name     string            // 渠道名称，为空时使用类型
+	URL      string            // webhook 地址
+	Secret   string            // 签名密钥，钉钉和飞书机器人开启签名校验时使用
+	Headers  map[string]string // 额外的请求头，仅 webhook 使用
+	MaxItems int               // 聊天消息中每个榜单最多展示的仓库数量，默认10
+	Timeout  time.Duration     // 请求超时时间，默认30秒
+}
+
+// NewNotifier 按类型创建 HTTP 通知渠道，邮件渠道使用 NewEmailNotifier 创建
+func NewNotifier(kind string, opts Options) (Notifier, error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("%s notifier requires a URL", kind)
+	}
+	if opts.MaxItems <= 0 {
+		opts.MaxItems = defaultMaxItems
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	kind = strings.ToLower(kind)
+	if opts.Name == "" {
+		opts.Name = kind
+	}
+
+	hook := &webhook{
+		name:       opts.Name,
+		url:        opts.URL,
+		httpClient: &http.Client{Timeout: opts.Timeout},
+	}
+	switch kind {
+	case TypeWebhook:
+		return &WebhookNotifier{webhook: hook, headers: opts.Headers}, nil
+	case TypeSlack:
+		return &SlackNotifier{webhook: hook, maxItems: opts.MaxItems}, nil
+	case TypeTeams:
+		return &TeamsNotifier{webhook: hook, maxItems: opts.MaxItems}, nil
+	case TypeDingTalk:
+		return &DingTalkNotifier{webhook: hook, secret: opts.Secret, maxItems: opts.MaxItems}, nil
+	case TypeFeishu:
+		return &FeishuNotifier{webhook: hook, secret: opts.Secret, maxItems: opts.MaxItems}, nil
+	case TypeWeCom:
+		return &WeComNotifier{webhook: hook, maxItems: opts.MaxItems}, nil
+	default:
+		return nil, fmt.Errorf("unknown notifier type: %s", kind)
+	}
+}
+
+// webhook 各 HTTP 通知渠道共用的请求逻辑
+type webhook struct {
+	name       string
+	url        string
+	httpClient *http.Client
+}
+
+// Name 渠道名称
+func (w *webhook) Name() string {
+	return w.name
+}
+
+// post 以 JSON 格式 POST payload，非 2xx 响应视为错误，返回响应体
+func (w *webhook) post(ctx context.Context, rawURL string, payload interface{}, header map[string]string) ([]byte, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s payload: %w", w.name, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s request: %w", w.name, err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("User-Agent", userAgent)
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		// webhook 地址中通常带有访问令牌，错误信息中去掉地址
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("failed to send %s request: %w", w.name, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s response: %w", w.name, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s returned status %d: %s", w.name, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// checkErrCode 检查钉钉、企业微信风格的响应 {"errcode": 0, "errmsg": "ok"}
+func (w *webhook) checkErrCode(body []byte) error {
+	var result struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", w.name, err)
+	}
+	if result.ErrCode != 0 {
+		return fmt.Errorf("%s returned error %d: %s", w.name, result.ErrCode, result.ErrMsg)
+	}
+	return nil
+}
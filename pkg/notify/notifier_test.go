@@ -0,0 +1,305 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github-insight-analyze/trending-notifier/pkg/formatter"
+)
+
+// received 测试服务器收到的请求
+type received struct {
+	query  url.Values
+	header http.Header
+	body   []byte
+}
+
+// newHookServer 启动记录请求的 webhook 服务器，按 status 和 response 响应
+func newHookServer(t *testing.T, status int, response string) (*httptest.Server, *received) {
+	t.Helper()
+	got := &received{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		got.query = r.URL.Query()
+		got.header = r.Header.Clone()
+		got.body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+	return server, got
+}
+
+// testNotification 两个榜单的报告，go 榜单有两个仓库
+func testNotification() *Notification {
+	return &Notification{
+		Report:   "go-daily",
+		Subject:  "Go Trending",
+		Body:     "<p>report</p>",
+		IsHTML:   true,
+		TextBody: "report",
+		Document: &formatter.ExportDocument{
+			SchemaVersion: formatter.SchemaVersion,
+			Language:      "go",
+			Period:        "daily",
+			Source:        "ossinsight",
+			Count:         3,
+			Repositories: []formatter.ExportRepository{
+				{Section: "go", Rank: 1, FullName: "a/one", URL: "https://github.com/a/one", Description: "fast <html> & more", Stars: 12345, StarsDelta: 1204},
+				{Section: "go", Rank: 2, FullName: "b/two", Stars: 7},
+				{Section: formatter.ExportSectionOverall, Rank: 1, FullName: "c/three", Stars: 1000},
+			},
+		},
+	}
+}
+
+func newTestNotifier(t *testing.T, kind, rawURL string, opts Options) Notifier {
+	t.Helper()
+	opts.URL = rawURL
+	if opts.MaxItems == 0 {
+		opts.MaxItems = 1
+	}
+	notifier, err := NewNotifier(kind, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return notifier
+}
+
+func decodeBody(t *testing.T, body []byte, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(body, v); err != nil {
+		t.Fatalf("invalid JSON payload: %v\n%s", err, body)
+	}
+}
+
+func TestWebhookPayload(t *testing.T) {
+	server, got := newHookServer(t, http.StatusNoContent, "")
+	notifier := newTestNotifier(t, TypeWebhook, server.URL, Options{Headers: map[string]string{"Authorization": "Bearer token"}})
+	if err := notifier.Notify(context.Background(), testNotification()); err != nil {
+		t.Fatal(err)
+	}
+
+	if h := got.header.Get("Authorization"); h != "Bearer token" {
+		t.Errorf("Authorization = %q", h)
+	}
+	if ct := got.header.Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	var payload WebhookPayload
+	decodeBody(t, got.body, &payload)
+	if payload.Report != "go-daily" || payload.Subject != "Go Trending" || payload.Text != "report" {
+		t.Errorf("payload = %+v, want report, subject and the plain text body", payload)
+	}
+	if payload.Sent.IsZero() || payload.Data == nil || len(payload.Data.Repositories) != 3 {
+		t.Errorf("payload sent/data = %v/%+v, want the full document", payload.Sent, payload.Data)
+	}
+}
+
+func TestSlackPayload(t *testing.T) {
+	server, got := newHookServer(t, http.StatusOK, "ok")
+	if err := newTestNotifier(t, TypeSlack, server.URL, Options{}).Notify(context.Background(), testNotification()); err != nil {
+		t.Fatal(err)
+	}
+
+	var msg slackMessage
+	decodeBody(t, got.body, &msg)
+	types := make([]string, 0, len(msg.Blocks))
+	for _, block := range msg.Blocks {
+		types = append(types, block.Type)
+	}
+	if want := "header section section context"; strings.Join(types, " ") != want {
+		t.Fatalf("blocks = %s, want %s", strings.Join(types, " "), want)
+	}
+	if msg.Text != "Go Trending" || msg.Blocks[0].Text.Text != "Go Trending" {
+		t.Errorf("text/header = %q/%q", msg.Text, msg.Blocks[0].Text.Text)
+	}
+	want := "*Go*\n1. <https://github.com/a/one|a/one> ★ 12,345 (+1,204) — fast &lt;html&gt; &amp; more\n…and 1 more\n"
+	if section := msg.Blocks[1].Text; section.Type != "mrkdwn" || section.Text != want {
+		t.Errorf("section = %q, want %q", section.Text, want)
+	}
+	if title := msg.Blocks[2].Text.Text; !strings.HasPrefix(title, "*Overall*\n1. <https://github.com/c/three|c/three>") {
+		t.Errorf("overall section = %q", title)
+	}
+	if footer := msg.Blocks[3].Elements[0].Text; footer != "Past 24 Hours · Source: ossinsight" {
+		t.Errorf("footer = %q", footer)
+	}
+}
+
+func TestTeamsPayload(t *testing.T) {
+	server, got := newHookServer(t, http.StatusAccepted, "1")
+	if err := newTestNotifier(t, TypeTeams, server.URL, Options{MaxItems: 2}).Notify(context.Background(), testNotification()); err != nil {
+		t.Fatal(err)
+	}
+
+	var msg teamsMessage
+	decodeBody(t, got.body, &msg)
+	if msg.Type != "message" || len(msg.Attachments) != 1 || msg.Attachments[0].ContentType != "application/vnd.microsoft.card.adaptive" {
+		t.Fatalf("message = %+v, want one adaptive card attachment", msg)
+	}
+	card := msg.Attachments[0].Content
+	if card.Type != "AdaptiveCard" || card.Version == "" || len(card.Body) != 6 {
+		t.Fatalf("card = %+v, want title, two sections and a footer", card)
+	}
+	if card.Body[0].Text != "Go Trending" || card.Body[1].Text != "Go" || card.Body[3].Text != "Overall" {
+		t.Errorf("titles = %q %q %q", card.Body[0].Text, card.Body[1].Text, card.Body[3].Text)
+	}
+	want := "1. [a/one](https://github.com/a/one) ★ 12,345 (+1,204) — fast <html> & more\n\n2. [b/two](https://github.com/b/two) ★ 7"
+	if items := card.Body[2].Text; items != want {
+		t.Errorf("items = %q, want %q", items, want)
+	}
+	if !card.Body[5].IsSubtle {
+		t.Errorf("footer block should be subtle: %+v", card.Body[5])
+	}
+}
+
+func TestDingTalkPayload(t *testing.T) {
+	server, got := newHookServer(t, http.StatusOK, `{"errcode":0,"errmsg":"ok"}`)
+	before := time.Now()
+	notifier := newTestNotifier(t, TypeDingTalk, server.URL+"/robot/send?access_token=abc", Options{Secret: "SECtest"})
+	if err := notifier.Notify(context.Background(), testNotification()); err != nil {
+		t.Fatal(err)
+	}
+
+	var msg dingTalkMessage
+	decodeBody(t, got.body, &msg)
+	if msg.MsgType != "markdown" || msg.Markdown.Title != "Go Trending" {
+		t.Errorf("message = %+v", msg)
+	}
+	if !strings.HasPrefix(msg.Markdown.Text, "### Go Trending\n\n**Go**\n1. [a/one](https://github.com/a/one) ★ 12,345 (+1,204)") ||
+		!strings.HasSuffix(msg.Markdown.Text, "\n> Past 24 Hours · Source: ossinsight\n") {
+		t.Errorf("text = %q", msg.Markdown.Text)
+	}
+
+	// 加签：access_token 保留，timestamp 为毫秒，sign = Base64(HmacSHA256(secret, timestamp + "\n" + secret))
+	if token := got.query.Get("access_token"); token != "abc" {
+		t.Errorf("access_token = %q, want abc", token)
+	}
+	timestamp := got.query.Get("timestamp")
+	ms, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || ms < before.UnixMilli() || ms > time.Now().UnixMilli() {
+		t.Errorf("timestamp = %q, want the current time in milliseconds", timestamp)
+	}
+	mac := hmac.New(sha256.New, []byte("SECtest"))
+	mac.Write([]byte(timestamp + "\nSECtest"))
+	if sign := got.query.Get("sign"); sign != base64.StdEncoding.EncodeToString(mac.Sum(nil)) {
+		t.Errorf("sign = %q does not match", sign)
+	}
+}
+
+func TestDingTalkWithoutSecret(t *testing.T) {
+	server, got := newHookServer(t, http.StatusOK, `{"errcode":0,"errmsg":"ok"}`)
+	if err := newTestNotifier(t, TypeDingTalk, server.URL+"?access_token=abc", Options{}).Notify(context.Background(), testNotification()); err != nil {
+		t.Fatal(err)
+	}
+	if got.query.Has("sign") || got.query.Has("timestamp") {
+		t.Errorf("query = %v, want no signature without a secret", got.query)
+	}
+}
+
+func TestFeishuPayload(t *testing.T) {
+	server, got := newHookServer(t, http.StatusOK, `{"code":0,"msg":"success"}`)
+	before := time.Now()
+	if err := newTestNotifier(t, TypeFeishu, server.URL, Options{Secret: "feishu-secret"}).Notify(context.Background(), testNotification()); err != nil {
+		t.Fatal(err)
+	}
+
+	var msg feishuMessage
+	decodeBody(t, got.body, &msg)
+	if msg.MsgType != "interactive" || msg.Card.Header.Title.Content != "Go Trending" {
+		t.Errorf("message = %+v", msg)
+	}
+	if len(msg.Card.Elements) != 2 || msg.Card.Elements[0].Tag != "div" || msg.Card.Elements[1].Tag != "note" {
+		t.Fatalf("elements = %+v, want a div and a note", msg.Card.Elements)
+	}
+	if text := msg.Card.Elements[0].Text; text.Tag != "lark_md" || !strings.HasPrefix(text.Content, "**Go**\n1. [a/one](https://github.com/a/one)") {
+		t.Errorf("div = %+v", text)
+	}
+
+	// 签名：以 timestamp + "\n" + secret 为密钥对空字符串做 HmacSHA256，timestamp 为秒
+	sec, err := strconv.ParseInt(msg.Timestamp, 10, 64)
+	if err != nil || sec < before.Unix() || sec > time.Now().Unix() {
+		t.Errorf("timestamp = %q, want the current time in seconds", msg.Timestamp)
+	}
+	mac := hmac.New(sha256.New, []byte(msg.Timestamp+"\nfeishu-secret"))
+	if msg.Sign != base64.StdEncoding.EncodeToString(mac.Sum(nil)) {
+		t.Errorf("sign = %q does not match", msg.Sign)
+	}
+}
+
+func TestWeComPayload(t *testing.T) {
+	server, got := newHookServer(t, http.StatusOK, `{"errcode":0,"errmsg":"ok"}`)
+	doc := testNotification()
+	for i := 0; i < 200; i++ {
+		doc.Document.Repositories = append(doc.Document.Repositories, formatter.ExportRepository{
+			Section: "rust", Rank: i + 1, FullName: "owner/repo-" + strconv.Itoa(i), Description: strings.Repeat("长描述", 20),
+		})
+	}
+	if err := newTestNotifier(t, TypeWeCom, server.URL, Options{MaxItems: 200}).Notify(context.Background(), doc); err != nil {
+		t.Fatal(err)
+	}
+
+	var msg weComMessage
+	decodeBody(t, got.body, &msg)
+	if msg.MsgType != "markdown" || !strings.HasPrefix(msg.Markdown.Content, "## Go Trending\n**Go**\n") {
+		t.Errorf("content = %.80q", msg.Markdown.Content)
+	}
+	if n := len(msg.Markdown.Content); n > weComMaxContent || !strings.HasSuffix(msg.Markdown.Content, "\n…") {
+		t.Errorf("content is %d bytes, want it truncated on a line boundary to at most %d", n, weComMaxContent)
+	}
+}
+
+func TestNotifierErrors(t *testing.T) {
+	tests := []struct {
+		kind     string
+		status   int
+		response string
+		want     string
+	}{
+		{TypeWebhook, http.StatusInternalServerError, "boom", "status 500: boom"},
+		{TypeSlack, http.StatusBadRequest, "invalid_payload", "status 400: invalid_payload"},
+		{TypeTeams, http.StatusForbidden, "", "status 403"},
+		{TypeDingTalk, http.StatusBadGateway, "", "status 502"},
+		{TypeFeishu, http.StatusServiceUnavailable, "", "status 503"},
+		{TypeWeCom, http.StatusNotFound, "", "status 404"},
+		// 钉钉、飞书和企业微信出错时返回 200，错误码在响应体中
+		{TypeDingTalk, http.StatusOK, `{"errcode":310000,"errmsg":"sign not match"}`, "error 310000: sign not match"},
+		{TypeFeishu, http.StatusOK, `{"code":19021,"msg":"sign match fail or timestamp is not within one hour from current time"}`, "error 19021"},
+		{TypeWeCom, http.StatusOK, `{"errcode":93000,"errmsg":"invalid webhook url"}`, "error 93000: invalid webhook url"},
+		{TypeWeCom, http.StatusOK, `not json`, "failed to decode wecom response"},
+	}
+
+	for _, tt := range tests {
+		server, _ := newHookServer(t, tt.status, tt.response)
+		err := newTestNotifier(t, tt.kind, server.URL+"?key=secret-token", Options{}).Notify(context.Background(), testNotification())
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s %d: err = %v, want it to contain %q", tt.kind, tt.status, err, tt.want)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.kind) {
+			t.Errorf("%s: err = %v, want it to name the notifier", tt.kind, err)
+		}
+	}
+}
+
+func TestNotifierErrorHidesURL(t *testing.T) {
+	server, _ := newHookServer(t, http.StatusOK, "")
+	server.Close()
+	err := newTestNotifier(t, TypeSlack, server.URL+"/services/secret-token", Options{}).Notify(context.Background(), testNotification())
+	if err == nil || strings.Contains(err.Error(), "secret-token") {
+		t.Errorf("err = %v, want an error without the webhook URL", err)
+	}
+}
@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Slack Block Kit 的长度限制
+const (
+	slackMaxHeader  = 150  // header 块最多 150 个字符
+	slackMaxSection = 3000 // section 块最多 3000 个字符
+	slackMaxBlocks  = 50   // 一条消息最多 50 个块
+)
+
+// SlackNotifier Slack incoming webhook，使用 Block Kit 发送
+type SlackNotifier struct {
+	*webhook
+	maxItems int
+}
+
+// slackMessage Slack 消息，text 用于通知预览和不支持 blocks 的客户端
+type slackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+// slackBlock Block Kit 块，只用到 header、section 和 context
+type slackBlock struct {
+	Type     string       `json:"type"`
+	Text     *slackText   `json:"text,omitempty"`
+	Elements []*slackText `json:"elements,omitempty"`
+}
+
+// slackText 文本对象
+type slackText struct {
+	Type string `json:"type"` // "plain_text" 或 "mrkdwn"
+	Text string `json:"text"`
+}
+
+// Notify 发送报告，每个榜单一个 section 块
+// 出错时 Slack 返回非 2xx 状态码和错误说明，如 "invalid_payload"
+func (s *SlackNotifier) Notify(ctx context.Context, n *Notification) error {
+	msg := &slackMessage{
+		Text: n.Subject,
+		Blocks: []slackBlock{{
+			Type: "header",
+			Text: &slackText{Type: "plain_text", Text: truncateRunes(n.Subject, slackMaxHeader)},
+		}},
+	}
+	for _, section := range summarize(n.Document, s.maxItems) {
+		if len(msg.Blocks) >= slackMaxBlocks-1 {
+			break
+		}
+		text := fmt.Sprintf("*%s*\n%s", escapeSlack(section.Title), sectionItems(section, slackLink, escapeSlack))
+		msg.Blocks = append(msg.Blocks, slackBlock{
+			Type: "section",
+			Text: &slackText{Type: "mrkdwn", Text: truncateLines(text, slackMaxSection)},
+		})
+	}
+	if footer := summaryFooter(n); footer != "" {
+		msg.Blocks = append(msg.Blocks, slackBlock{
+			Type:     "context",
+			Elements: []*slackText{{Type: "mrkdwn", Text: escapeSlack(footer)}},
+		})
+	}
+
+	_, err := s.post(ctx, s.url, msg, nil)
+	return err
+}
+
+// slackLink Slack mrkdwn 链接 <url|text>
+func slackLink(text, url string) string {
+	return fmt.Sprintf("<%s|%s>", url, escapeSlack(text))
+}
+
+// slackEscaper Slack mrkdwn 中需要转义的字符
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// escapeSlack 转义 &、< 和 >
+func escapeSlack(s string) string {
+	return slackEscaper.Replace(s)
+}
@@ -0,0 +1,157 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/github-insight-analyze/trending-notifier/pkg/formatter"
+)
+
+// 聊天消息中描述的最大长度（字符数）
+const maxDescription = 100
+
+// summarySection 聊天消息中的一个榜单
+type summarySection struct {
+	Title string
+	Repos []formatter.ExportRepository
+	More  int // 超出 maxItems 未展示的仓库数量
+}
+
+// summarize 按 section 分组，每个榜单最多保留 maxItems 个仓库
+func summarize(doc *formatter.ExportDocument, maxItems int) []summarySection {
+	if doc == nil {
+		return nil
+	}
+
+	var sections []summarySection
+	index := make(map[string]int)
+	for _, repo := range doc.Repositories {
+		i, ok := index[repo.Section]
+		if !ok {
+			i = len(sections)
+			index[repo.Section] = i
+			sections = append(sections, summarySection{Title: sectionTitle(repo.Section)})
+		}
+		if len(sections[i].Repos) < maxItems {
+			sections[i].Repos = append(sections[i].Repos, repo)
+		} else {
+			sections[i].More++
+		}
+	}
+	return sections
+}
+
+// markdownSummary 生成 Markdown 格式的榜单
+// 钉钉、飞书、企业微信和 Teams 都支持 **bold** 和 [text](url) 链接
+func markdownSummary(n *Notification, maxItems int) string {
+	var sb strings.Builder
+	for _, section := range summarize(n.Document, maxItems) {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(fmt.Sprintf("**%s**\n", section.Title))
+		sb.WriteString(sectionItems(section, standardLink, nil))
+	}
+	return sb.String()
+}
+
+// sectionItems 榜单中的仓库，每行一个，如 "1. [owner/name](url) ★ 1,234 (+56) — description"
+// link 生成仓库链接，escape 转义描述中的特殊字符，为 nil 时原样输出；如 Slack 使用 <url|text> 链接并转义 <、>
+func sectionItems(section summarySection, link func(text, url string) string, escape func(string) string) string {
+	var sb strings.Builder
+	for _, repo := range section.Repos {
+		sb.WriteString(fmt.Sprintf("%d. %s %s", repo.Rank, link(repo.FullName, repoURL(repo)), formatStars(repo)))
+		if desc := truncateRunes(singleLine(repo.Description), maxDescription); desc != "" {
+			if escape != nil {
+				desc = escape(desc)
+			}
+			sb.WriteString(" — " + desc)
+		}
+		sb.WriteString("\n")
+	}
+	if section.More > 0 {
+		sb.WriteString(fmt.Sprintf("…and %d more\n", section.More))
+	}
+	return sb.String()
+}
+
+// summaryFooter 时间范围和数据源
+func summaryFooter(n *Notification) string {
+	if n.Document == nil {
+		return ""
+	}
+	parts := []string{formatter.FormatPeriod(n.Document.Period)}
+	if n.Document.Source != "" {
+		parts = append(parts, "Source: "+n.Document.Source)
+	}
+	if n.Document.Ranking != "" {
+		parts = append(parts, "Ranking: "+n.Document.Ranking)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// standardLink 标准 Markdown 链接
+func standardLink(text, url string) string {
+	text = strings.NewReplacer("[", "(", "]", ")").Replace(text)
+	url = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29").Replace(url)
+	return fmt.Sprintf("[%s](%s)", text, url)
+}
+
+// repoURL 仓库地址，数据源未提供时根据仓库全名生成
+func repoURL(repo formatter.ExportRepository) string {
+	if repo.URL != "" {
+		return repo.URL
+	}
+	return "https://github.com/" + repo.FullName
+}
+
+// formatStars 格式化 star 数和增量，如 "★ 1,234 (+56)"
+func formatStars(repo formatter.ExportRepository) string {
+	s := "★ " + formatter.FormatNumber(repo.Stars)
+	if repo.StarsDelta > 0 {
+		s += fmt.Sprintf(" (+%s)", formatter.FormatNumber(repo.StarsDelta))
+	}
+	return s
+}
+
+// sectionTitle 榜单标题，如 "Go"、"All Languages"、"Overall"
+func sectionTitle(section string) string {
+	if section == formatter.ExportSectionOverall {
+		return "Overall"
+	}
+	return formatter.FormatLanguage(section)
+}
+
+// singleLine 合并换行
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// truncateRunes 截取前 n 个字符，超出时以 "…" 结尾
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:n-1]) + "…"
+}
+
+// truncateLines 按整行截取，保证不超过 maxBytes 字节，超出时以 "…" 结尾
+// 各平台对消息长度有限制，如企业微信 markdown 消息不超过 4096 字节
+func truncateLines(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	const ellipsis = "…"
+	cut := strings.LastIndex(s[:maxBytes-len(ellipsis)], "\n")
+	if cut < 0 {
+		// 单行超长时按字符截断
+		cut = maxBytes - len(ellipsis)
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		return s[:cut] + ellipsis
+	}
+	return s[:cut+1] + ellipsis
+}
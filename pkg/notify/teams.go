@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"context"
+	"strings"
+)
+
+// TeamsNotifier Microsoft Teams incoming webhook 或 Workflows webhook，发送 Adaptive Card
+type TeamsNotifier struct {
+	*webhook
+	maxItems int
+}
+
+// teamsMessage 包含一张 Adaptive Card 的消息
+type teamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+// teamsAttachment 消息附件
+type teamsAttachment struct {
+	ContentType string    `json:"contentType"`
+	Content     teamsCard `json:"content"`
+}
+
+// teamsCard Adaptive Card
+type teamsCard struct {
+	Schema  string                 `json:"$schema"`
+	Type    string                 `json:"type"`
+	Version string                 `json:"version"`
+	Body    []teamsTextBlock       `json:"body"`
+	MSTeams map[string]interface{} `json:"msteams,omitempty"`
+}
+
+// teamsTextBlock Adaptive Card 文本块，支持部分 Markdown 语法
+type teamsTextBlock struct {
+	Type     string `json:"type"`
+	Text     string `json:"text"`
+	Size     string `json:"size,omitempty"`
+	Weight   string `json:"weight,omitempty"`
+	IsSubtle bool   `json:"isSubtle,omitempty"`
+	Wrap     bool   `json:"wrap"`
+}
+
+// Notify 发送报告，每个榜单一个标题和一个列表
+func (t *TeamsNotifier) Notify(ctx context.Context, n *Notification) error {
+	card := teamsCard{
+		Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+		Type:    "AdaptiveCard",
+		Version: "1.4",
+		Body: []teamsTextBlock{
+			{Type: "TextBlock", Text: n.Subject, Size: "Large", Weight: "Bolder", Wrap: true},
+		},
+		MSTeams: map[string]interface{}{"width": "Full"},
+	}
+	for _, section := range summarize(n.Document, t.maxItems) {
+		// Adaptive Card 的 Markdown 中单个换行不会分段，列表项之间使用空行
+		items := strings.TrimSuffix(sectionItems(section, standardLink, nil), "\n")
+		card.Body = append(card.Body,
+			teamsTextBlock{Type: "TextBlock", Text: section.Title, Size: "Medium", Weight: "Bolder", Wrap: true},
+			teamsTextBlock{Type: "TextBlock", Text: strings.ReplaceAll(items, "\n", "\n\n"), Wrap: true},
+		)
+	}
+	if footer := summaryFooter(n); footer != "" {
+		card.Body = append(card.Body, teamsTextBlock{Type: "TextBlock", Text: footer, IsSubtle: true, Wrap: true})
+	}
+
+	msg := &teamsMessage{
+		Type: "message",
+		Attachments: []teamsAttachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content:     card,
+		}},
+	}
+	_, err := t.post(ctx, t.url, msg, nil)
+	return err
+}
@@ -0,0 +1,40 @@
+package notify
+
+import (
+	"context"
+	"time"
+
+	"github.com/github-insight-analyze/trending-notifier/pkg/formatter"
+)
+
+// WebhookNotifier 通用 webhook，POST JSON 格式的完整报告
+type WebhookNotifier struct {
+	*webhook
+	headers map[string]string
+}
+
+// WebhookPayload 通用 webhook 的请求体
+type WebhookPayload struct {
+	Report  string                    `json:"report"`  // 报告名称
+	Subject string                    `json:"subject"` // 报告标题
+	Sent    time.Time                 `json:"sent"`    // 发送时间（RFC 3339）
+	Text    string                    `json:"text"`    // 纯文本报告，HTML 报告使用其纯文本版本
+	Data    *formatter.ExportDocument `json:"data"`    // 与 JSON 导出格式相同的结构化数据
+}
+
+// Notify 发送报告
+func (w *WebhookNotifier) Notify(ctx context.Context, n *Notification) error {
+	text := n.Body
+	if n.IsHTML {
+		text = n.TextBody
+	}
+	payload := &WebhookPayload{
+		Report:  n.Report,
+		Subject: n.Subject,
+		Sent:    time.Now().UTC().Truncate(time.Second),
+		Text:    text,
+		Data:    n.Document,
+	}
+	_, err := w.post(ctx, w.url, payload, w.headers)
+	return err
+}
@@ -0,0 +1,39 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+)
+
+// 企业微信 markdown 消息的最大长度（字节）
+const weComMaxContent = 4096
+
+// WeComNotifier 企业微信群机器人，发送 markdown 消息
+type WeComNotifier struct {
+	*webhook
+	maxItems int
+}
+
+// weComMessage 企业微信 markdown 消息
+type weComMessage struct {
+	MsgType  string `json:"msgtype"`
+	Markdown struct {
+		Content string `json:"content"`
+	} `json:"markdown"`
+}
+
+// Notify 发送报告，超出长度限制时截断，企业微信出错时同样返回 200，错误码在响应的 errcode 中
+func (w *WeComNotifier) Notify(ctx context.Context, n *Notification) error {
+	msg := &weComMessage{MsgType: "markdown"}
+	content := fmt.Sprintf("## %s\n%s", n.Subject, markdownSummary(n, w.maxItems))
+	if footer := summaryFooter(n); footer != "" {
+		content += "\n> " + footer + "\n"
+	}
+	msg.Markdown.Content = truncateLines(content, weComMaxContent)
+
+	body, err := w.post(ctx, w.url, msg, nil)
+	if err != nil {
+		return err
+	}
+	return w.checkErrCode(body)
+}
@@ -0,0 +1,144 @@
+package rank
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/github-insight-analyze/trending-notifier/pkg/api"
+)
+
+// 排名策略名称
+const (
+	StrategySource       = "source"        // 保持数据源返回的顺序
+	StrategyStars        = "stars"         // star 数
+	StrategyStarVelocity = "star_velocity" // 每天新增 star 数（StarsDelta / 天数），没有增量数据时为 0
+	StrategyForks        = "forks"         // fork 数
+	StrategyPushes       = "pushes"        // push 数
+	StrategyPullRequests = "pull_requests" // PR 数
+	StrategyTotalScore   = "total_score"   // OSSInsight 综合得分
+	StrategyWeighted     = "weighted"      // 按权重组合以上指标
+)
+
+// Strategy 排名策略，按得分从高到低排序
+type Strategy struct {
+	name    string
+	weights map[string]float64
+	score   func(repo *api.Repository) float64 // 为 nil 时保持原有顺序
+}
+
+// New 创建排名策略
+// period 用于计算每天新增 star 数，weights 仅在 weighted 策略下使用，键为指标名称（stars、forks 等）
+func New(name string, period string, weights map[string]float64) (*Strategy, error) {
+	name = strings.ToLower(name)
+	if name == "" {
+		name = StrategySource
+	}
+
+	if name == StrategySource {
+		return &Strategy{name: name}, nil
+	}
+
+	if name == StrategyWeighted {
+		if len(weights) == 0 {
+			return nil, fmt.Errorf("weighted ranking requires at least one weight")
+		}
+		metrics := make(map[string]func(*api.Repository) float64, len(weights))
+		for metric := range weights {
+			fn, ok := metricFunc(metric, period)
+			if !ok {
+				return nil, fmt.Errorf("unknown ranking metric: %s", metric)
+			}
+			metrics[metric] = fn
+		}
+		return &Strategy{
+			name:    name,
+			weights: weights,
+			score: func(repo *api.Repository) float64 {
+				var total float64
+				for metric, fn := range metrics {
+					total += weights[metric] * fn(repo)
+				}
+				return total
+			},
+		}, nil
+	}
+
+	fn, ok := metricFunc(name, period)
+	if !ok {
+		return nil, fmt.Errorf("unknown ranking strategy: %s", name)
+	}
+	return &Strategy{name: name, score: fn}, nil
+}
+
+// Name 策略名称
+func (s *Strategy) Name() string {
+	return s.name
+}
+
+// IsSource 是否保持数据源顺序
+func (s *Strategy) IsSource() bool {
+	return s.score == nil
+}
+
+// String 策略说明，用于报告元信息，如 "weighted (forks×0.5, stars×1)"
+func (s *Strategy) String() string {
+	if s.name != StrategyWeighted {
+		return s.name
+	}
+
+	metrics := make([]string, 0, len(s.weights))
+	for metric := range s.weights {
+		metrics = append(metrics, metric)
+	}
+	sort.Strings(metrics)
+
+	parts := make([]string, 0, len(metrics))
+	for _, metric := range metrics {
+		parts = append(parts, metric+"×"+strconv.FormatFloat(s.weights[metric], 'g', -1, 64))
+	}
+	return fmt.Sprintf("%s (%s)", s.name, strings.Join(parts, ", "))
+}
+
+// Apply 按策略对仓库排序并重新计算 Rank（从 1 开始连续编号）
+// 得分相同时保持原有顺序，source 策略只重新编号
+func (s *Strategy) Apply(repos []api.Repository) {
+	if s.score != nil {
+		scores := make(map[string]float64, len(repos))
+		for i := range repos {
+			scores[repos[i].Key()] = s.score(&repos[i])
+		}
+		sort.SliceStable(repos, func(i, j int) bool {
+			return scores[repos[i].Key()] > scores[repos[j].Key()]
+		})
+	}
+
+	for i := range repos {
+		repos[i].Rank = i + 1
+	}
+}
+
+// metricFunc 返回指标对应的取值函数
+func metricFunc(metric string, period string) (func(*api.Repository) float64, bool) {
+	switch metric {
+	case StrategyStars:
+		return func(repo *api.Repository) float64 { return float64(repo.TotalStars()) }, true
+	case StrategyStarVelocity:
+		days := float64(api.PeriodDays(period))
+		return func(repo *api.Repository) float64 {
+			// 所有仓库统一使用本期的 star 增量，没有增量数据时视为 0，排在有新增 star 的仓库之后
+			return float64(repo.StarsDelta) / days
+		}, true
+	case StrategyForks:
+		return func(repo *api.Repository) float64 { return float64(repo.TotalForks()) }, true
+	case StrategyPushes:
+		return func(repo *api.Repository) float64 { return float64(repo.Pushes) }, true
+	case StrategyPullRequests:
+		return func(repo *api.Repository) float64 { return float64(repo.PullRequests) }, true
+	case StrategyTotalScore:
+		return func(repo *api.Repository) float64 { return repo.TotalScore }, true
+	default:
+		return nil, false
+	}
+}
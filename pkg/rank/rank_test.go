@@ -0,0 +1,32 @@
+package rank
+
+import (
+	"testing"
+
+	"github.com/github-insight-analyze/trending-notifier/pkg/api"
+)
+
+func TestStarVelocityUsesDeltaForEveryRepo(t *testing.T) {
+	repos := []api.Repository{
+		{RepoName: "a/no-delta", Stars: 5000},
+		{RepoName: "b/shrinking", Stars: 500, StarsDelta: -10},
+		{RepoName: "c/growing", Stars: 1000, StarsDelta: 5},
+		{RepoName: "d/fastest", Stars: 100, StarsDelta: 70},
+	}
+
+	strategy, err := New(StrategyStarVelocity, "weekly", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	strategy.Apply(repos)
+
+	want := []string{"d/fastest", "c/growing", "a/no-delta", "b/shrinking"}
+	for i, name := range want {
+		if repos[i].RepoName != name {
+			t.Errorf("rank %d = %s, want %s", i+1, repos[i].RepoName, name)
+		}
+		if repos[i].Rank != i+1 {
+			t.Errorf("%s Rank = %d, want %d", repos[i].RepoName, repos[i].Rank, i+1)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule 解析后的 cron 表达式，在指定时区中计算运行时间
+// 支持标准的 5 个字段（分 时 日 月 周）以及 @hourly、@daily、@weekly、@monthly、@yearly 等简写
+type Schedule struct {
+	expr     string
+	location *time.Location
+	minute   uint64 // 各字段允许的取值，按位表示
+	hour     uint64
+	dom      uint64
+	month    uint64
+	dow      uint64
+	domStar  bool // 日字段为 *，此时只按周匹配
+	dowStar  bool // 周字段为 *，此时只按日匹配
+}
+
+// field cron 字段的取值范围
+type field struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int // 月份和星期的英文缩写
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 星期中 0 和 7 都表示周日
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// descriptors cron 简写
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// 查找下一次运行时间的最大范围，超出时认为表达式永远不会触发（如 2 月 30 日）
+const maxLookahead = 5 * 366 * 24 * time.Hour
+
+// Parse 解析 cron 表达式，location 为 nil 时使用本地时区
+func Parse(expr string, location *time.Location) (*Schedule, error) {
+	if location == nil {
+		location = time.Local
+	}
+
+	spec := strings.TrimSpace(expr)
+	if strings.HasPrefix(spec, "@") {
+		d, ok := descriptors[strings.ToLower(spec)]
+		if !ok {
+			return nil, fmt.Errorf("invalid cron expression %q: unknown descriptor", expr)
+		}
+		spec = d
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields (minute hour day-of-month month day-of-week), got %d", expr, len(fields))
+	}
+
+	s := &Schedule{
+		expr:     strings.TrimSpace(expr),
+		location: location,
+		domStar:  fields[2] == "*" || fields[2] == "?",
+		dowStar:  fields[4] == "*" || fields[4] == "?",
+	}
+	var err error
+	for _, f := range []struct {
+		spec  string
+		field field
+		dst   *uint64
+	}{
+		{fields[0], minuteField, &s.minute},
+		{fields[1], hourField, &s.hour},
+		{fields[2], domField, &s.dom},
+		{fields[3], monthField, &s.month},
+		{fields[4], dowField, &s.dow},
+	} {
+		if *f.dst, err = parseField(f.spec, f.field); err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+	}
+	// 周日统一使用 0
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+
+	if s.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("invalid cron expression %q: never fires", expr)
+	}
+	return s, nil
+}
+
+// parseField 解析一个字段，支持 *、列表（1,2）、范围（1-5）、步长（*/15、1-30/5）和英文缩写（MON、JAN）
+func parseField(spec string, f field) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(spec, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %s field: %q", f.name, part)
+			}
+			rangePart, step = part[:i], n
+		}
+
+		var lo, hi int
+		switch {
+		case rangePart == "*" || rangePart == "?":
+			lo, hi = f.min, f.max
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if lo, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(bounds[1]); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range in %s field: %q", f.name, rangePart)
+			}
+		default:
+			var err error
+			if lo, err = f.value(rangePart); err != nil {
+				return 0, err
+			}
+			hi = lo
+			// "5/10" 表示从 5 开始每 10 个单位
+			if step > 1 {
+				hi = f.max
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// value 解析单个取值，检查范围
+func (f field) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value in %s field: %q", f.name, s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s must be between %d and %d, got %d", f.name, f.min, f.max, v)
+	}
+	return v, nil
+}
+
+// String 原始的 cron 表达式
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Location 计算运行时间使用的时区
+func (s *Schedule) Location() *time.Location {
+	return s.location
+}
+
+// Next 返回 t 之后（不含 t）的下一次运行时间，找不到时返回零值
+// 夏令时开始时跳过的时间不会触发，结束时重复的时间只触发一次
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.In(s.location)
+	limit := t.Add(maxLookahead)
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, s.location).Add(time.Minute)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.location)
+			continue
+		}
+		if !s.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.location)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = skipRepeated(t, t.Add(time.Duration(60-t.Minute())*time.Minute))
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = skipRepeated(t, t.Add(time.Minute))
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// skipRepeated 夏令时结束时时钟回拨，跳过重复出现的时间段
+func skipRepeated(prev, next time.Time) time.Time {
+	_, prevOffset := prev.Zone()
+	_, nextOffset := next.Zone()
+	if nextOffset < prevOffset {
+		return next.Add(time.Duration(prevOffset-nextOffset) * time.Second)
+	}
+	return next
+}
+
+// matchDay 日和周都有限制时满足其一即可，与 Vixie cron 一致
+func (s *Schedule) matchDay(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.domStar && s.dowStar:
+		return true
+	case s.domStar:
+		return dowMatch
+	case s.dowStar:
+		return domMatch
+	default:
+		return domMatch || dowMatch
+	}
+}
@@ -0,0 +1,261 @@
+package schedule
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata" // 夏令时测试需要真实时区，不依赖系统的 zoneinfo
+)
+
+func mustParse(t *testing.T, expr string, location *time.Location) *Schedule {
+	t.Helper()
+	s, err := Parse(expr, location)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", expr, err)
+	}
+	return s
+}
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return location
+}
+
+// bitsOf 返回按位表示的取值列表
+func bitsOf(bits uint64) []int {
+	var values []int
+	for v := 0; v < 64; v++ {
+		if bits&(1<<uint(v)) != 0 {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		spec  string
+		field field
+		want  []int
+	}{
+		{"*", hourField, nil}, // 0-23，下面单独检查
+		{"5", minuteField, []int{5}},
+		{"1,15,30", minuteField, []int{1, 15, 30}},
+		{"9-12", hourField, []int{9, 10, 11, 12}},
+		{"*/15", minuteField, []int{0, 15, 30, 45}},
+		{"10-30/10", minuteField, []int{10, 20, 30}},
+		{"5/20", minuteField, []int{5, 25, 45}},
+		{"1-5,0", dowField, []int{0, 1, 2, 3, 4, 5}},
+		{"MON-FRI", dowField, []int{1, 2, 3, 4, 5}},
+		{"sat,Sun", dowField, []int{0, 6}},
+		{"7", dowField, []int{7}},
+		{"JAN,jul-sep", monthField, []int{1, 7, 8, 9}},
+		{"?", domField, nil},
+	}
+
+	for _, tt := range tests {
+		bits, err := parseField(tt.spec, tt.field)
+		if err != nil {
+			t.Errorf("%s %q: %v", tt.field.name, tt.spec, err)
+			continue
+		}
+		got := bitsOf(bits)
+		if tt.want == nil {
+			if len(got) != tt.field.max-tt.field.min+1 || got[0] != tt.field.min {
+				t.Errorf("%s %q = %v, want every value", tt.field.name, tt.spec, got)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s %q = %v, want %v", tt.field.name, tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"", "expected 5 fields"},
+		{"* * * *", "expected 5 fields"},
+		{"* * * * * *", "expected 5 fields"},
+		{"@every 5m", "unknown descriptor"},
+		{"60 * * * *", "minute must be between 0 and 59"},
+		{"* 24 * * *", "hour must be between 0 and 23"},
+		{"* * 0 * *", "day of month must be between 1 and 31"},
+		{"* * * 13 * ", "month must be between 1 and 12"},
+		{"* * * * 8", "day of week must be between 0 and 7"},
+		{"* * * FOO *", "invalid value in month field"},
+		{"* * * * MON-", "invalid value in day of week field"},
+		{"5-1 * * * *", "invalid range in minute field"},
+		{"*/0 * * * *", "invalid step in minute field"},
+		{"*/x * * * *", "invalid step in minute field"},
+		{"1,,2 * * * *", "invalid value in minute field"},
+		{"0 0 30 2 *", "never fires"},
+		{"0 0 31 4,6,9,11 *", "never fires"},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.expr, time.UTC)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) err = %v, want it to contain %q", tt.expr, err, tt.want)
+		}
+	}
+}
+
+func TestParseDescriptors(t *testing.T) {
+	from := time.Date(2026, 3, 15, 10, 30, 0, 0, time.UTC) // 周日
+	tests := map[string]time.Time{
+		"@yearly":   time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		"@annually": time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		"@monthly":  time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		"@weekly":   time.Date(2026, 3, 22, 0, 0, 0, 0, time.UTC),
+		"@daily":    time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC),
+		"@MIDNIGHT": time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC),
+		"@hourly":   time.Date(2026, 3, 15, 11, 0, 0, 0, time.UTC),
+	}
+	for expr, want := range tests {
+		if got := mustParse(t, expr, time.UTC).Next(from); !got.Equal(want) {
+			t.Errorf("%s: Next = %v, want %v", expr, got, want)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	shanghai := mustLoad(t, "Asia/Shanghai")
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"same day", "30 7 * * *", time.Date(2026, 5, 4, 6, 0, 0, 0, shanghai), time.Date(2026, 5, 4, 7, 30, 0, 0, shanghai)},
+		{"excludes from", "30 7 * * *", time.Date(2026, 5, 4, 7, 30, 0, 0, shanghai), time.Date(2026, 5, 5, 7, 30, 0, 0, shanghai)},
+		{"drops seconds", "30 7 * * *", time.Date(2026, 5, 4, 7, 29, 59, 999, shanghai), time.Date(2026, 5, 4, 7, 30, 0, 0, shanghai)},
+		{"month boundary", "0 8 * * *", time.Date(2026, 4, 30, 9, 0, 0, 0, shanghai), time.Date(2026, 5, 1, 8, 0, 0, 0, shanghai)},
+		{"31st skips short months", "0 0 31 * *", time.Date(2026, 4, 1, 0, 0, 0, 0, shanghai), time.Date(2026, 5, 31, 0, 0, 0, 0, shanghai)},
+		{"year boundary", "0 0 1 1 *", time.Date(2026, 12, 31, 23, 59, 0, 0, shanghai), time.Date(2027, 1, 1, 0, 0, 0, 0, shanghai)},
+		{"december to january", "15 9 * JAN MON", time.Date(2026, 12, 1, 0, 0, 0, 0, shanghai), time.Date(2027, 1, 4, 9, 15, 0, 0, shanghai)},
+		{"leap day", "0 12 29 2 *", time.Date(2026, 3, 1, 0, 0, 0, 0, shanghai), time.Date(2028, 2, 29, 12, 0, 0, 0, shanghai)},
+		{"weekdays from friday", "0 8 * * MON-FRI", time.Date(2026, 5, 8, 9, 0, 0, 0, shanghai), time.Date(2026, 5, 11, 8, 0, 0, 0, shanghai)},
+		{"sunday as 7", "0 8 * * 7", time.Date(2026, 5, 4, 0, 0, 0, 0, shanghai), time.Date(2026, 5, 10, 8, 0, 0, 0, shanghai)},
+		{"every 15 minutes", "*/15 * * * *", time.Date(2026, 5, 4, 23, 50, 0, 0, shanghai), time.Date(2026, 5, 5, 0, 0, 0, 0, shanghai)},
+		{"converts from utc", "0 8 * * *", time.Date(2026, 5, 4, 1, 0, 0, 0, time.UTC), time.Date(2026, 5, 5, 8, 0, 0, 0, shanghai)},
+	}
+
+	for _, tt := range tests {
+		got := mustParse(t, tt.expr, shanghai).Next(tt.from)
+		if !got.Equal(tt.want) {
+			t.Errorf("%s: Next(%v) = %v, want %v", tt.name, tt.from, got, tt.want)
+		}
+		if got.Location() != shanghai {
+			t.Errorf("%s: Next returned %v, want a time in the schedule's location", tt.name, got.Location())
+		}
+	}
+}
+
+// 日和周都有限制时满足其一即可（Vixie cron），只有一个有限制时只按它匹配
+func TestNextDayOfMonthOrDayOfWeek(t *testing.T) {
+	// 2026-05-01 为周五
+	from := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want []time.Time
+	}{
+		{"0 0 13 * FRI", []time.Time{
+			time.Date(2026, 5, 8, 0, 0, 0, 0, time.UTC),
+			time.Date(2026, 5, 13, 0, 0, 0, 0, time.UTC),
+			time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC),
+		}},
+		{"0 0 13 * *", []time.Time{
+			time.Date(2026, 5, 13, 0, 0, 0, 0, time.UTC),
+			time.Date(2026, 6, 13, 0, 0, 0, 0, time.UTC),
+		}},
+		{"0 0 * * FRI", []time.Time{
+			time.Date(2026, 5, 8, 0, 0, 0, 0, time.UTC),
+			time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC),
+		}},
+		{"0 0 ? * 5", []time.Time{
+			time.Date(2026, 5, 8, 0, 0, 0, 0, time.UTC),
+			time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC),
+		}},
+		// 日字段为 */10 时同样算作有限制
+		{"0 0 */10 * SUN", []time.Time{
+			time.Date(2026, 5, 3, 0, 0, 0, 0, time.UTC),
+			time.Date(2026, 5, 10, 0, 0, 0, 0, time.UTC),
+			time.Date(2026, 5, 11, 0, 0, 0, 0, time.UTC),
+			time.Date(2026, 5, 17, 0, 0, 0, 0, time.UTC),
+		}},
+	}
+
+	for _, tt := range tests {
+		s := mustParse(t, tt.expr, time.UTC)
+		got := from
+		for _, want := range tt.want {
+			if got = s.Next(got); !got.Equal(want) {
+				t.Errorf("%s: Next = %v, want %v", tt.expr, got, want)
+				break
+			}
+		}
+	}
+}
+
+// 夏令时开始时跳过的时间不会触发，结束时重复的时间只触发一次
+func TestNextDaylightSaving(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want []string // 依次调用 Next 的结果（RFC 3339）
+	}{
+		// 2026-03-08 02:00 EST 时钟拨到 03:00 EDT，02:30 不存在
+		{"spring forward skips the gap", "30 2 * * *", time.Date(2026, 3, 7, 12, 0, 0, 0, newYork), []string{
+			"2026-03-09T02:30:00-04:00",
+			"2026-03-10T02:30:00-04:00",
+		}},
+		{"spring forward hourly", "0 * * * *", time.Date(2026, 3, 8, 0, 30, 0, 0, newYork), []string{
+			"2026-03-08T01:00:00-05:00",
+			"2026-03-08T03:00:00-04:00",
+			"2026-03-08T04:00:00-04:00",
+		}},
+		{"spring forward after the gap", "30 3 * * *", time.Date(2026, 3, 7, 12, 0, 0, 0, newYork), []string{
+			"2026-03-08T03:30:00-04:00",
+			"2026-03-09T03:30:00-04:00",
+		}},
+		// 2026-11-01 02:00 EDT 时钟回拨到 01:00 EST，01:00-01:59 出现两次
+		{"fall back fires once", "30 1 * * *", time.Date(2026, 10, 31, 12, 0, 0, 0, newYork), []string{
+			"2026-11-01T01:30:00-04:00",
+			"2026-11-02T01:30:00-05:00",
+		}},
+		{"fall back hourly", "0 * * * *", time.Date(2026, 11, 1, 0, 30, 0, 0, newYork), []string{
+			"2026-11-01T01:00:00-04:00",
+			"2026-11-01T02:00:00-05:00",
+			"2026-11-01T03:00:00-05:00",
+		}},
+		{"fall back every 20 minutes", "*/20 1 * * *", time.Date(2026, 11, 1, 0, 59, 0, 0, newYork), []string{
+			"2026-11-01T01:00:00-04:00",
+			"2026-11-01T01:20:00-04:00",
+			"2026-11-01T01:40:00-04:00",
+			"2026-11-02T01:00:00-05:00",
+		}},
+	}
+
+	for _, tt := range tests {
+		s := mustParse(t, tt.expr, newYork)
+		got := make([]string, 0, len(tt.want))
+		for next := tt.from; len(got) < len(tt.want); {
+			next = s.Next(next)
+			got = append(got, next.Format(time.RFC3339))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
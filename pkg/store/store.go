@@ -0,0 +1,334 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/github-insight-analyze/trending-notifier/pkg/api"
+)
+
+// 快照文件名使用的时间格式（UTC，可按字典序排序）
+const fileTimeLayout = "20060102T150405.000000000Z"
+
+// 快照文件扩展名
+const snapshotExt = ".jsonl"
+
+// ErrNotFound 快照不存在
+var ErrNotFound = errors.New("snapshot not found")
+
+// Store 本地快照存储
+// 目录结构: <dir>/<language>/<period>/<fetched_at>.jsonl
+// 每个文件第一行为快照头信息，其后每行一个仓库，写入后不再修改
+type Store struct {
+	dir string
+}
+
+// Snapshot 一次 trending 抓取的快照
+type Snapshot struct {
+	SnapshotInfo
+	Repos []api.Repository
+}
+
+// SnapshotInfo 快照元信息（即文件头）
+type SnapshotInfo struct {
+	ID        string    `json:"id"`
+	Language  string    `json:"language"`
+	Period    string    `json:"period"`
+	Source    string    `json:"source,omitempty"` // 实际返回结果的数据源，如 "ossinsight"、"github_trending"，旧快照中为空
+	FetchedAt time.Time `json:"fetched_at"`
+	Count     int       `json:"count"`
+}
+
+// Filter 快照查询条件，空值表示不限制
+type Filter struct {
+	Language string
+	Period   string
+	Source   string
+	Since    time.Time
+	Until    time.Time
+}
+
+// NewStore 创建快照存储，目录不存在时自动创建
+func NewStore(dir string) (*Store, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("store directory is required")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Save 保存一次抓取结果，返回快照元信息，source 为实际返回结果的数据源
+func (s *Store) Save(language, period, source string, fetchedAt time.Time, repos []api.Repository) (*SnapshotInfo, error) {
+	language = normalizeKey(language, "all")
+	period = normalizeKey(period, "daily")
+	fetchedAt = fetchedAt.UTC()
+
+	info := SnapshotInfo{
+		ID:        snapshotID(language, period, fetchedAt),
+		Language:  language,
+		Period:    period,
+		Source:    source,
+		FetchedAt: fetchedAt,
+		Count:     len(repos),
+	}
+
+	dir := filepath.Join(s.dir, language, period)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	// 先写临时文件再重命名，避免中途失败留下不完整的快照
+	tmp, err := os.CreateTemp(dir, ".snapshot-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(info); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("failed to write snapshot header: %w", err)
+	}
+	for _, repo := range repos {
+		if err := enc.Encode(repo); err != nil {
+			tmp.Close()
+			return nil, fmt.Errorf("failed to write snapshot: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path(info.ID)); err != nil {
+		return nil, fmt.Errorf("failed to save snapshot: %w", err)
+	}
+
+	return &info, nil
+}
+
+// List 列出符合条件的快照，按抓取时间升序排列
+func (s *Store) List(filter Filter) ([]SnapshotInfo, error) {
+	pattern := filepath.Join(s.dir,
+		globKey(filter.Language), globKey(filter.Period), "*"+snapshotExt)
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	infos := make([]SnapshotInfo, 0, len(files))
+	for _, file := range files {
+		info, err := readHeader(file)
+		if err != nil {
+			return nil, err
+		}
+		if filter.Source != "" && info.Source != filter.Source {
+			continue
+		}
+		if !filter.Since.IsZero() && info.FetchedAt.Before(filter.Since) {
+			continue
+		}
+		if !filter.Until.IsZero() && info.FetchedAt.After(filter.Until) {
+			continue
+		}
+		infos = append(infos, *info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].FetchedAt.Before(infos[j].FetchedAt)
+	})
+
+	return infos, nil
+}
+
+// Load 按 ID 加载完整快照
+func (s *Store) Load(id string) (*Snapshot, error) {
+	f, err := os.Open(s.path(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(bufio.NewReader(f))
+
+	var snap Snapshot
+	if err := dec.Decode(&snap.SnapshotInfo); err != nil {
+		return nil, fmt.Errorf("failed to read snapshot header %s: %w", id, err)
+	}
+
+	snap.Repos = make([]api.Repository, 0, snap.Count)
+	for dec.More() {
+		var repo api.Repository
+		if err := dec.Decode(&repo); err != nil {
+			return nil, fmt.Errorf("failed to read snapshot %s: %w", id, err)
+		}
+		snap.Repos = append(snap.Repos, repo)
+	}
+
+	return &snap, nil
+}
+
+// Latest 加载指定语言和时间范围最近的一次快照，不限数据源
+// 没有快照时返回 ErrNotFound
+func (s *Store) Latest(language, period string) (*Snapshot, error) {
+	return s.LatestBefore(language, period, time.Time{})
+}
+
+// LatestFrom 加载指定数据源在该语言和时间范围最近的一次快照
+// 不同数据源的 star 数、排名含义不同，计算增量时只与同一数据源的快照对比；没有快照时返回 ErrNotFound
+func (s *Store) LatestFrom(language, period, source string) (*Snapshot, error) {
+	if source == "" {
+		return nil, ErrNotFound
+	}
+	return s.latest(Filter{Language: language, Period: period, Source: source}, time.Time{})
+}
+
+// LatestBefore 加载指定时间之前最近的一次快照，before 为零值时不限制
+// 没有快照时返回 ErrNotFound
+func (s *Store) LatestBefore(language, period string, before time.Time) (*Snapshot, error) {
+	return s.latest(Filter{Language: language, Period: period}, before)
+}
+
+// latest 加载符合条件且早于 before 的最近一次快照
+func (s *Store) latest(filter Filter, before time.Time) (*Snapshot, error) {
+	filter.Language = normalizeKey(filter.Language, "all")
+	filter.Period = normalizeKey(filter.Period, "daily")
+	infos, err := s.List(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(infos) - 1; i >= 0; i-- {
+		if before.IsZero() || infos[i].FetchedAt.Before(before) {
+			return s.Load(infos[i].ID)
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// Prune 删除早于 before 的快照，但每个语言/时间范围/数据源至少保留最近 keepLast 个
+// 返回删除的快照数量
+func (s *Store) Prune(before time.Time, keepLast int) (int, error) {
+	infos, err := s.List(Filter{})
+	if err != nil {
+		return 0, err
+	}
+
+	// 按语言/时间范围/数据源分组，List 已按时间升序排列
+	groups := make(map[string][]SnapshotInfo)
+	for _, info := range infos {
+		key := info.Language + "/" + info.Period + "/" + info.Source
+		groups[key] = append(groups[key], info)
+	}
+
+	removed := 0
+	for _, group := range groups {
+		for i, info := range group {
+			if len(group)-i <= keepLast {
+				break
+			}
+			if !info.FetchedAt.Before(before) {
+				continue
+			}
+			if err := os.Remove(s.path(info.ID)); err != nil && !os.IsNotExist(err) {
+				return removed, fmt.Errorf("failed to remove snapshot %s: %w", info.ID, err)
+			}
+			removed++
+		}
+	}
+
+	return removed, nil
+}
+
+// MarkSent 记录某个快照已作为指定报告发送，供该报告下次生成差异时对比
+func (s *Store) MarkSent(id, report string) error {
+	info, err := readHeader(s.path(id))
+	if err != nil {
+		return err
+	}
+
+	path := s.sentPath(info.Language, info.Period, report)
+	if err := os.WriteFile(path, []byte(info.ID+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to mark snapshot as sent: %w", err)
+	}
+	return nil
+}
+
+// LastSent 加载指定报告在该语言和时间范围下最近一次发送过的快照
+// 从未发送或对应快照已被清理时返回 ErrNotFound
+func (s *Store) LastSent(language, period, report string) (*Snapshot, error) {
+	path := s.sentPath(normalizeKey(language, "all"), normalizeKey(period, "daily"), report)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to read sent marker: %w", err)
+	}
+	return s.Load(strings.TrimSpace(string(data)))
+}
+
+// sentPath 记录某个报告最近一次发送快照 ID 的文件路径
+func (s *Store) sentPath(language, period, report string) string {
+	return filepath.Join(s.dir, language, period, "last_sent."+normalizeKey(report, "default"))
+}
+
+// path 快照 ID 对应的文件路径
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(id)+snapshotExt)
+}
+
+// readHeader 读取快照文件头
+func readHeader(path string) (*SnapshotInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer f.Close()
+
+	var info SnapshotInfo
+	if err := json.NewDecoder(bufio.NewReader(f)).Decode(&info); err != nil {
+		return nil, fmt.Errorf("failed to read snapshot header %s: %w", path, err)
+	}
+	return &info, nil
+}
+
+// snapshotID 生成快照 ID，格式为 language/period/time
+func snapshotID(language, period string, fetchedAt time.Time) string {
+	return language + "/" + period + "/" + fetchedAt.Format(fileTimeLayout)
+}
+
+// normalizeKey 将语言、时间范围转换为可用作目录名的形式
+func normalizeKey(s, fallback string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return fallback
+	}
+	replacer := strings.NewReplacer("/", "_", "\\", "_", " ", "_", "*", "_", "?", "_", "[", "_", "]", "_")
+	return replacer.Replace(s)
+}
+
+// globKey 查询条件为空时匹配全部目录
+func globKey(s string) string {
+	if s == "" {
+		return "*"
+	}
+	return normalizeKey(s, "*")
+}
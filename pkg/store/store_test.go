@@ -0,0 +1,84 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/github-insight-analyze/trending-notifier/pkg/api"
+)
+
+func TestLatestFromSameSource(t *testing.T) {
+	s, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	saves := []struct {
+		source string
+		stars  int
+	}{
+		{"", 1},
+		{api.SourceOSSInsight, 10},
+		{api.SourceGitHubTrending, 20},
+		{api.SourceOSSInsight, 11},
+		{api.SourceGitHubTrending, 21},
+	}
+	for i, save := range saves {
+		repos := []api.Repository{{FullName: "a/one", Stars: save.stars}}
+		if _, err := s.Save("Go", "daily", save.source, start.Add(time.Duration(i)*time.Hour), repos); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snap, err := s.LatestFrom("go", "daily", api.SourceOSSInsight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Source != api.SourceOSSInsight || snap.Repos[0].Stars != 11 {
+		t.Errorf("LatestFrom(ossinsight) = %s with %d stars, want ossinsight with 11", snap.Source, snap.Repos[0].Stars)
+	}
+
+	if _, err := s.LatestFrom("go", "daily", api.SourceGitHub); !errors.Is(err, ErrNotFound) {
+		t.Errorf("LatestFrom(github) err = %v, want ErrNotFound", err)
+	}
+	// 旧快照没有记录数据源，不参与增量计算
+	if _, err := s.LatestFrom("go", "daily", ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("LatestFrom(\"\") err = %v, want ErrNotFound", err)
+	}
+
+	latest, err := s.Latest("go", "daily")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.Source != api.SourceGitHubTrending {
+		t.Errorf("Latest source = %q, want %s regardless of source", latest.Source, api.SourceGitHubTrending)
+	}
+}
+
+func TestPruneKeepsLastPerSource(t *testing.T) {
+	s, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	sources := []string{api.SourceOSSInsight, api.SourceOSSInsight, api.SourceGitHubTrending, api.SourceOSSInsight}
+	for i, source := range sources {
+		if _, err := s.Save("go", "daily", source, start.Add(time.Duration(i)*time.Hour), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := s.Prune(start.Add(24*time.Hour), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 2 {
+		t.Errorf("removed = %d, want 2", removed)
+	}
+	for _, source := range []string{api.SourceOSSInsight, api.SourceGitHubTrending} {
+		if _, err := s.LatestFrom("go", "daily", source); err != nil {
+			t.Errorf("LatestFrom(%s) after prune: %v", source, err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package trend
+
+import (
+	"github.com/github-insight-analyze/trending-notifier/pkg/api"
+)
+
+// DeltaStats 增量计算结果统计
+type DeltaStats struct {
+	Matched int // 在上一次快照中出现过的仓库数量
+	New     int // 本次新上榜的仓库数量
+}
+
+// ApplyDeltas 将本次抓取结果与上一次快照对比，填充增量字段
+// 会修改 current 中的 StarsDelta、ForksDelta、StargazersDelta、PreviousRank 和 RankDelta
+// 数据源已给出的增量（如 github.com/trending 的 "stars today"）保持不变，
+// 上一次未上榜的仓库不计算增量
+func ApplyDeltas(current []api.Repository, previous []api.Repository) DeltaStats {
+	var stats DeltaStats
+
+	prevByKey := indexByKey(previous)
+
+	for i := range current {
+		repo := &current[i]
+		rank := rankOf(repo, i)
+
+		prev, ok := prevByKey[repo.Key()]
+		if !ok {
+			repo.PreviousRank = 0
+			repo.RankDelta = 0
+			stats.New++
+			continue
+		}
+
+		if repo.StarsDelta == 0 {
+			repo.StarsDelta = repo.TotalStars() - prev.repo.TotalStars()
+		}
+		if repo.ForksDelta == 0 {
+			repo.ForksDelta = repo.TotalForks() - prev.repo.TotalForks()
+		}
+		if repo.StargazersDelta == 0 {
+			repo.StargazersDelta = repo.Stargazers - prev.repo.Stargazers
+		}
+		repo.PreviousRank = prev.rank
+		repo.RankDelta = prev.rank - rank
+		stats.Matched++
+	}
+
+	return stats
+}
+
+// rankedRepo 带排名的仓库
+type rankedRepo struct {
+	repo *api.Repository
+	rank int
+}
+
+// indexByKey 按仓库唯一标识建立索引
+func indexByKey(repos []api.Repository) map[string]rankedRepo {
+	index := make(map[string]rankedRepo, len(repos))
+	for i := range repos {
+		key := repos[i].Key()
+		if _, exists := index[key]; exists {
+			continue
+		}
+		index[key] = rankedRepo{repo: &repos[i], rank: rankOf(&repos[i], i)}
+	}
+	return index
+}
+
+// rankOf 返回仓库排名，未设置时使用在列表中的位置
+func rankOf(repo *api.Repository, index int) int {
+	if repo.Rank > 0 {
+		return repo.Rank
+	}
+	return index + 1
+}
+
+// ApplyRankChanges 只重新计算排名变化（PreviousRank 和 RankDelta），不修改增量字段
+// 用于过滤和重新排名之后，与经过同样处理的上一次快照对比
+func ApplyRankChanges(current []api.Repository, previous []api.Repository) {
+	prevByKey := indexByKey(previous)
+
+	for i := range current {
+		repo := &current[i]
+		prev, ok := prevByKey[repo.Key()]
+		if !ok {
+			repo.PreviousRank = 0
+			repo.RankDelta = 0
+			continue
+		}
+		repo.PreviousRank = prev.rank
+		repo.RankDelta = prev.rank - rankOf(repo, i)
+	}
+}
@@ -0,0 +1,94 @@
+package trend
+
+import (
+	"sort"
+	"time"
+
+	"github.com/github-insight-analyze/trending-notifier/pkg/api"
+)
+
+// ReportDiff 本次报告与上一次已发送报告的差异
+type ReportDiff struct {
+	BaselineTime time.Time   // 上一次报告的数据抓取时间
+	New          []DiffEntry // 新上榜的仓库，按本次排名排序
+	Still        []DiffEntry // 仍在榜的仓库，上升最多的排在前面
+	Dropped      []DiffEntry // 掉出榜单的仓库，按上次排名排序
+}
+
+// DiffEntry 差异报告中的一个仓库
+type DiffEntry struct {
+	Repo         api.Repository // 仓库信息，掉榜的仓库为上一次报告中的数据
+	Rank         int            // 本次排名，掉榜时为 0
+	PreviousRank int            // 上次排名，新上榜时为 0
+	RankDelta    int            // 排名变化，正数表示上升
+}
+
+// Diff 对比本次和上一次报告的仓库列表
+func Diff(current []api.Repository, previous []api.Repository, baselineTime time.Time) *ReportDiff {
+	diff := &ReportDiff{BaselineTime: baselineTime}
+
+	prevByKey := indexByKey(previous)
+	seen := make(map[string]bool, len(current))
+
+	for i := range current {
+		repo := &current[i]
+		key := repo.Key()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		rank := rankOf(repo, i)
+		prev, ok := prevByKey[key]
+		if !ok {
+			diff.New = append(diff.New, DiffEntry{Repo: *repo, Rank: rank})
+			continue
+		}
+
+		diff.Still = append(diff.Still, DiffEntry{
+			Repo:         *repo,
+			Rank:         rank,
+			PreviousRank: prev.rank,
+			RankDelta:    prev.rank - rank,
+		})
+	}
+
+	for i := range previous {
+		repo := &previous[i]
+		key := repo.Key()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		diff.Dropped = append(diff.Dropped, DiffEntry{
+			Repo:         *repo,
+			PreviousRank: rankOf(repo, i),
+		})
+	}
+
+	sort.SliceStable(diff.Still, func(i, j int) bool {
+		if diff.Still[i].RankDelta != diff.Still[j].RankDelta {
+			return diff.Still[i].RankDelta > diff.Still[j].RankDelta
+		}
+		return diff.Still[i].Rank < diff.Still[j].Rank
+	})
+
+	return diff
+}
+
+// Climbers 返回排名上升的仓库
+func (d *ReportDiff) Climbers() []DiffEntry {
+	var climbers []DiffEntry
+	for _, entry := range d.Still {
+		if entry.RankDelta > 0 {
+			climbers = append(climbers, entry)
+		}
+	}
+	return climbers
+}
+
+// IsEmpty 判断是否没有任何差异可展示
+func (d *ReportDiff) IsEmpty() bool {
+	return d == nil || (len(d.New) == 0 && len(d.Still) == 0 && len(d.Dropped) == 0)
+}
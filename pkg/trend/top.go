@@ -0,0 +1,43 @@
+package trend
+
+import (
+	"sort"
+
+	"github.com/github-insight-analyze/trending-notifier/pkg/api"
+)
+
+// TopAcross 合并多个榜单（如不同语言的 trending），去重后按 star 数取前 n 名
+// star 数相同时按增量排序，仍相同时保持原榜单顺序；n <= 0 时返回全部
+// 返回的仓库 Rank 为合并后的排名，各榜单内的排名变化在合并榜单中没有意义，会被清零
+func TopAcross(lists [][]api.Repository, n int) []api.Repository {
+	var merged []api.Repository
+	seen := make(map[string]bool)
+	for _, repos := range lists {
+		for _, repo := range repos {
+			key := repo.Key()
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, repo)
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		si, sj := merged[i].TotalStars(), merged[j].TotalStars()
+		if si != sj {
+			return si > sj
+		}
+		return merged[i].StarsDelta > merged[j].StarsDelta
+	})
+
+	if n > 0 && len(merged) > n {
+		merged = merged[:n]
+	}
+	for i := range merged {
+		merged[i].Rank = i + 1
+		merged[i].PreviousRank = 0
+		merged[i].RankDelta = 0
+	}
+	return merged
+}